@@ -0,0 +1,238 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/dilithium/mode2"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+	"github.com/cloudflare/circl/sign/dilithium/mode5"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"slices"
+)
+
+// The circl KEM keys pack into slices and encapsulate into caller buffers through these methods, schemes without
+// them fall back to MarshalBinary / Encapsulate and append the result
+
+type slicePacker interface {
+	Pack(buf []byte)
+}
+
+type encapsulatorTo interface {
+	EncapsulateTo(ct, ss, seed []byte)
+}
+
+// sigAppender packs keys and signs into caller buffers for the signature schemes whose circl packages only offer
+// this on their concrete types
+type sigAppender struct {
+	packPublic  func(key any, buf []byte) bool
+	packPrivate func(key any, buf []byte) bool
+	signTo      func(key any, msg, sig []byte) (bool, error)
+}
+
+var sigAppenders = map[sign.Scheme]sigAppender{
+	mldsa44.Scheme(): {
+		packPublic:  packSig(func(k *mldsa44.PublicKey, b []byte) { k.Pack((*[mldsa44.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mldsa44.PrivateKey, b []byte) { k.Pack((*[mldsa44.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mldsa44.PrivateKey, msg, sig []byte) error {
+			return mldsa44.SignTo(k, msg, nil, false, sig)
+		}),
+	},
+	mldsa65.Scheme(): {
+		packPublic:  packSig(func(k *mldsa65.PublicKey, b []byte) { k.Pack((*[mldsa65.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mldsa65.PrivateKey, b []byte) { k.Pack((*[mldsa65.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mldsa65.PrivateKey, msg, sig []byte) error {
+			return mldsa65.SignTo(k, msg, nil, false, sig)
+		}),
+	},
+	mldsa87.Scheme(): {
+		packPublic:  packSig(func(k *mldsa87.PublicKey, b []byte) { k.Pack((*[mldsa87.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mldsa87.PrivateKey, b []byte) { k.Pack((*[mldsa87.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mldsa87.PrivateKey, msg, sig []byte) error {
+			return mldsa87.SignTo(k, msg, nil, false, sig)
+		}),
+	},
+	mode2.Scheme(): {
+		packPublic:  packSig(func(k *mode2.PublicKey, b []byte) { k.Pack((*[mode2.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mode2.PrivateKey, b []byte) { k.Pack((*[mode2.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mode2.PrivateKey, msg, sig []byte) error {
+			mode2.SignTo(k, msg, sig)
+			return nil
+		}),
+	},
+	mode3.Scheme(): {
+		packPublic:  packSig(func(k *mode3.PublicKey, b []byte) { k.Pack((*[mode3.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mode3.PrivateKey, b []byte) { k.Pack((*[mode3.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mode3.PrivateKey, msg, sig []byte) error {
+			mode3.SignTo(k, msg, sig)
+			return nil
+		}),
+	},
+	mode5.Scheme(): {
+		packPublic:  packSig(func(k *mode5.PublicKey, b []byte) { k.Pack((*[mode5.PublicKeySize]byte)(b)) }),
+		packPrivate: packSig(func(k *mode5.PrivateKey, b []byte) { k.Pack((*[mode5.PrivateKeySize]byte)(b)) }),
+		signTo: signSig(func(k *mode5.PrivateKey, msg, sig []byte) error {
+			mode5.SignTo(k, msg, sig)
+			return nil
+		}),
+	},
+}
+
+// packSig adapts a pack function for the concrete key type K, false is returned for other key types
+func packSig[K any](pack func(k *K, buf []byte)) func(key any, buf []byte) bool {
+	return func(key any, buf []byte) bool {
+		k, ok := key.(*K)
+		if ok {
+			pack(k, buf)
+		}
+		return ok
+	}
+}
+
+// signSig adapts a sign function for the concrete key type K, false is returned for other key types
+func signSig[K any](signTo func(k *K, msg, sig []byte) error) func(key any, msg, sig []byte) (bool, error) {
+	return func(key any, msg, sig []byte) (bool, error) {
+		k, ok := key.(*K)
+		if !ok {
+			return false, nil
+		}
+		return true, signTo(k, msg, sig)
+	}
+}
+
+// grow extends b by n bytes, returning the extended slice and the new n bytes
+func grow(b []byte, n int) ([]byte, []byte) {
+	b = slices.Grow(b, n)
+	return b[:len(b)+n], b[len(b) : len(b)+n]
+}
+
+// appendMarshalled is the fallback for keys without a packer
+func appendMarshalled(b []byte, key interface{ MarshalBinary() ([]byte, error) }) ([]byte, error) {
+	bts, err := key.MarshalBinary()
+	if err != nil {
+		return b, err
+	}
+	return append(b, bts...), nil
+}
+
+// AppendBinary appends the binary key to b, see encoding.BinaryAppender
+func (k KemPublicKeyWrapper) AppendBinary(b []byte) ([]byte, error) {
+	if k.PublicKey == nil {
+		return b, crypto.ErrKeyNil
+	}
+	if p, ok := k.PublicKey.(slicePacker); ok {
+		b, buf := grow(b, k.PublicKey.Scheme().PublicKeySize())
+		p.Pack(buf)
+		return b, nil
+	}
+	return appendMarshalled(b, k.PublicKey)
+}
+
+// AppendBinary appends the binary key to b, see encoding.BinaryAppender
+func (k KemPrivateKeyWrapper) AppendBinary(b []byte) ([]byte, error) {
+	if k.PrivateKey == nil {
+		return b, crypto.ErrKeyNil
+	}
+	if p, ok := k.PrivateKey.(slicePacker); ok {
+		b, buf := grow(b, k.PrivateKey.Scheme().PrivateKeySize())
+		p.Pack(buf)
+		return b, nil
+	}
+	return appendMarshalled(b, k.PrivateKey)
+}
+
+// AppendBinary appends the binary key to b, see encoding.BinaryAppender
+func (k SigPublicKeyWrapper) AppendBinary(b []byte) ([]byte, error) {
+	if k.PublicKey == nil {
+		return b, crypto.ErrKeyNil
+	}
+	scheme := k.PublicKey.Scheme()
+	if a, ok := sigAppenders[scheme]; ok {
+		nb, buf := grow(b, scheme.PublicKeySize())
+		if a.packPublic(k.PublicKey, buf) {
+			return nb, nil
+		}
+	}
+	return appendMarshalled(b, k.PublicKey)
+}
+
+// AppendBinary appends the binary key to b, see encoding.BinaryAppender
+func (k SigPrivateKeyWrapper) AppendBinary(b []byte) ([]byte, error) {
+	if k.PrivateKey == nil {
+		return b, crypto.ErrKeyNil
+	}
+	scheme := k.PrivateKey.Scheme()
+	if a, ok := sigAppenders[scheme]; ok {
+		nb, buf := grow(b, scheme.PrivateKeySize())
+		if a.packPrivate(k.PrivateKey, buf) {
+			return nb, nil
+		}
+	}
+	return appendMarshalled(b, k.PrivateKey)
+}
+
+// AppendEncapsulate is Encapsulate appending the ciphertext to ct and the shared secret to ss
+func (k KemWrapper) AppendEncapsulate(ct, ss []byte, key crypto.KemPublicKey) ([]byte, []byte, error) {
+	if err := k.modeCheck(); err != nil {
+		return ct, ss, err
+	}
+	if key == nil {
+		return ct, ss, crypto.ErrKeyNil
+	}
+	wk, ok := key.(*KemPublicKeyWrapper)
+	if !ok {
+		return ct, ss, crypto.ErrIncompatibleKey
+	}
+	if wk.PublicKey.Scheme() != k.wrapped {
+		return ct, ss, kem.ErrTypeMismatch
+	}
+	if e, ok := wk.PublicKey.(encapsulatorTo); ok {
+		ct, ctBuf := grow(ct, k.wrapped.CiphertextSize())
+		ss, ssBuf := grow(ss, k.wrapped.SharedKeySize())
+		e.EncapsulateTo(ctBuf, ssBuf, nil)
+		return ct, ss, nil
+	}
+	ctxt, secret, err := k.wrapped.Encapsulate(wk.PublicKey)
+	if err != nil {
+		return ct, ss, err
+	}
+	return append(ct, ctxt...), append(ss, secret...), nil
+}
+
+// AppendSign is Sign appending the signature to sig
+func (s SigWrapper) AppendSign(sig []byte, key crypto.SigPrivateKey, msg []byte) (out []byte, err error) {
+	if err := s.modeCheck(); err != nil {
+		return sig, err
+	}
+	if key == nil {
+		return sig, crypto.ErrKeyNil
+	}
+	wk, ok := key.(*SigPrivateKeyWrapper)
+	if !ok {
+		return sig, crypto.ErrIncompatibleKey
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				out, err = sig, e
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	if a, ok := sigAppenders[s.wrapped]; ok {
+		nsig, buf := grow(sig, s.wrapped.SignatureSize())
+		if signed, err := a.signTo(wk.PrivateKey, msg, buf); signed {
+			if err != nil {
+				return sig, err
+			}
+			return nsig, nil
+		}
+		// a key of another scheme, let the wrapped scheme report the mismatch
+	}
+	return append(sig, s.wrapped.Sign(wk.PrivateKey, msg, nil)...), nil
+}
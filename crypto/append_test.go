@@ -0,0 +1,116 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"encoding"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var _ encoding.BinaryAppender = KemPublicKeyWrapper{}
+var _ encoding.BinaryAppender = KemPrivateKeyWrapper{}
+var _ encoding.BinaryAppender = SigPublicKeyWrapper{}
+var _ encoding.BinaryAppender = SigPrivateKeyWrapper{}
+
+func assertAppendBinary(t *testing.T, key interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryAppender
+}) {
+	bts, err := key.MarshalBinary()
+	assert.NoError(t, err)
+	prefix := []byte("prefix")
+	out, err := key.AppendBinary(prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), bts...), out)
+	buf := make([]byte, 0, len(bts))
+	assert.Zero(t, testing.AllocsPerRun(10, func() {
+		buf, _ = key.AppendBinary(buf[:0])
+	}))
+}
+
+func TestKemAppend(t *testing.T) {
+	for _, s := range knownKemSchemes {
+		scheme := WrapKem(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			assertAppendBinary(t, pk.(*KemPublicKeyWrapper))
+			assertAppendBinary(t, k.(*KemPrivateKeyWrapper))
+
+			ct, ss, err := scheme.AppendEncapsulate([]byte{1}, []byte{2}, pk)
+			assert.NoError(t, err)
+			assert.Len(t, ct, 1+scheme.CiphertextSize())
+			assert.Len(t, ss, 1+scheme.SharedKeySize())
+			assert.Equal(t, byte(1), ct[0])
+			assert.Equal(t, byte(2), ss[0])
+			secret, err := scheme.Decapsulate(k, ct[1:])
+			assert.NoError(t, err)
+			assert.Equal(t, secret, ss[1:])
+		})
+	}
+}
+
+func TestSigAppend(t *testing.T) {
+	msg := []byte("hello world")
+	for _, s := range knownSigSchemes {
+		scheme := WrapSig(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			assertAppendBinary(t, pk.(*SigPublicKeyWrapper))
+			assertAppendBinary(t, k.(*SigPrivateKeyWrapper))
+
+			sig, err := scheme.AppendSign([]byte{1}, k, msg)
+			assert.NoError(t, err)
+			assert.Len(t, sig, 1+scheme.SignatureSize())
+			assert.Equal(t, byte(1), sig[0])
+			stxt, err := scheme.Sign(k, msg)
+			assert.NoError(t, err)
+			assert.Equal(t, stxt, sig[1:])
+			v, err := scheme.Verify(pk, msg, sig[1:])
+			assert.NoError(t, err)
+			assert.True(t, v)
+
+			// circl allocates internally while signing, only the signature buffer is saved
+			buf := make([]byte, 0, scheme.SignatureSize())
+			assert.Less(t, testing.AllocsPerRun(10, func() {
+				buf, _ = scheme.AppendSign(buf[:0], k, msg)
+			}), testing.AllocsPerRun(10, func() {
+				_, _ = scheme.Sign(k, msg)
+			}))
+		})
+	}
+}
+
+func TestAppendInvalid(t *testing.T) {
+	_, err := KemPublicKeyWrapper{}.AppendBinary(nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	_, err = SigPrivateKeyWrapper{}.AppendBinary(nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+
+	kemScheme := WrapKem(mlkem768.Scheme())
+	pk512, _, err := WrapKem(mlkem512.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	ct, ss, err := kemScheme.AppendEncapsulate([]byte{1}, nil, pk512)
+	assert.ErrorIs(t, err, kem.ErrTypeMismatch)
+	assert.Equal(t, []byte{1}, ct)
+	assert.Nil(t, ss)
+	_, _, err = kemScheme.AppendEncapsulate(nil, nil, nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+
+	sigScheme := WrapSig(mldsa44.Scheme())
+	_, k65, err := WrapSig(mldsa65.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	sig, err := sigScheme.AppendSign([]byte{1}, k65, []byte("hello"))
+	assert.Error(t, err)
+	assert.Equal(t, []byte{1}, sig)
+	_, err = sigScheme.AppendSign(nil, nil, nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
@@ -0,0 +1,231 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"math/bits"
+	"time"
+)
+
+// Batch signing signs the root of a Merkle tree (RFC 9162 hashing with SHA-256) over many KEM public keys once, each
+// key then carries an inclusion proof instead of its own signature. The signed data is:
+//
+//	"PQCMB" | version 1 | tree size u64 | root hash
+//
+// as the PublicKey of a crypto.SigData so issue and expiry times work as they do for single keys.
+
+var ErrInvalidBatch = errors.New("invalid batch signature")
+
+const batchRootMagic = "PQCMB\x01"
+
+// maxBatchProof is the longest inclusion proof, enough for 2^64 leaves
+const maxBatchProof = 64
+
+func merkleLeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func merkleNodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleSplit is the largest power of two smaller than n
+func merkleSplit(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// merkleProofs returns the root of the tree over leaves appending the inclusion proof of each leaf to proofs
+func merkleProofs(leaves [][]byte, proofs [][][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	left := merkleProofs(leaves[:k], proofs[:k])
+	right := merkleProofs(leaves[k:], proofs[k:])
+	for i := range proofs[:k] {
+		proofs[i] = append(proofs[i], right)
+	}
+	for i := range proofs[k:] {
+		proofs[k+i] = append(proofs[k+i], left)
+	}
+	return merkleNodeHash(left, right)
+}
+
+// merkleRoot computes the root from a leaf hash and its inclusion proof (RFC 9162 section 2.1.3.2)
+func merkleRoot(leaf []byte, index, size uint64, proof [][]byte) ([]byte, bool) {
+	if index >= size {
+		return nil, false
+	}
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range proof {
+		if sn == 0 {
+			return nil, false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = merkleNodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return r, sn == 0
+}
+
+func batchRootData(size uint64, root []byte) []byte {
+	b := append([]byte(batchRootMagic), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(b[len(batchRootMagic):], size)
+	return append(b, root...)
+}
+
+// BatchSigData is the part of a batch signature for one key, see crypto.SigData
+type BatchSigData struct {
+	// PublicKey is the signed data, not included by MarshalBinary
+	PublicKey []byte
+	Index     uint64
+	Size      uint64
+	Proof     [][]byte
+	// Root is the signature over the tree root shared by the whole batch, its PublicKey is not included by
+	// MarshalBinary
+	Root *crypto.SigData
+}
+
+// NewBatchSigData signs data with a single signature, returning the BatchSigData for each in order, see
+// crypto.NewSigData
+func NewBatchSigData(data [][]byte, issue, expiry time.Time, h hash.Hash, key crypto.SigPrivateKey) ([]*BatchSigData, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if len(data) == 0 {
+		return nil, ErrInvalidBatch
+	}
+	leaves := make([][]byte, len(data))
+	for i, d := range data {
+		leaves[i] = merkleLeafHash(d)
+	}
+	proofs := make([][][]byte, len(data))
+	root := merkleProofs(leaves, proofs)
+	size := uint64(len(data))
+	rootSig, err := NewSigData(batchRootData(size, root), issue, expiry, h, key)
+	if err != nil {
+		return nil, err
+	}
+	if rootSig == nil {
+		return nil, ErrInvalidBatch
+	}
+	batch := make([]*BatchSigData, len(data))
+	for i, d := range data {
+		batch[i] = &BatchSigData{PublicKey: d, Index: uint64(i), Size: size, Proof: proofs[i], Root: rootSig}
+	}
+	return batch, nil
+}
+
+// NewKemBatchSigData is NewBatchSigData over the binary KEM public keys
+func NewKemBatchSigData(keys []crypto.KemPublicKey, issue, expiry time.Time, h hash.Hash, key crypto.SigPrivateKey) ([]*BatchSigData, error) {
+	data := make([][]byte, len(keys))
+	for i, k := range keys {
+		if k == nil {
+			return nil, crypto.ErrKeyNil
+		}
+		bts, err := k.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data[i] = bts
+	}
+	return NewBatchSigData(data, issue, expiry, h, key)
+}
+
+// Verify checks the inclusion proof of PublicKey and the root signature made by key, see crypto.SigData.Verify
+func (b *BatchSigData) Verify(h hash.Hash, key crypto.SigPublicKey) bool {
+	if key == nil || b.Root == nil || len(b.Proof) > maxBatchProof {
+		return false
+	}
+	for _, p := range b.Proof {
+		if len(p) != sha256.Size {
+			return false
+		}
+	}
+	root, ok := merkleRoot(merkleLeafHash(b.PublicKey), b.Index, b.Size, b.Proof)
+	if !ok {
+		return false
+	}
+	rootSig := *b.Root
+	rootSig.PublicKey = batchRootData(b.Size, root)
+	if b.Root.PublicKey != nil && !bytes.Equal(b.Root.PublicKey, rootSig.PublicKey) {
+		return false
+	}
+	valid, err := VerifySigData(&rootSig, h, key)
+	return err == nil && valid
+}
+
+// MarshalBinary encodes the index, size, proof and root signature:
+//
+//	index u64 | size u64 | proof length u8 | proof | root SigData
+func (b *BatchSigData) MarshalBinary() ([]byte, error) {
+	if b.Root == nil || len(b.Proof) > maxBatchProof {
+		return nil, ErrInvalidBatch
+	}
+	out := binary.BigEndian.AppendUint64(nil, b.Index)
+	out = binary.BigEndian.AppendUint64(out, b.Size)
+	out = append(out, byte(len(b.Proof)))
+	for _, p := range b.Proof {
+		if len(p) != sha256.Size {
+			return nil, ErrInvalidBatch
+		}
+		out = append(out, p...)
+	}
+	rootSig, err := b.Root.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(out, rootSig...), nil
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary, PublicKey is unchanged
+func (b *BatchSigData) UnmarshalBinary(bts []byte) error {
+	if len(bts) < 17 {
+		return ErrInvalidBatch
+	}
+	n := int(bts[16])
+	if n > maxBatchProof || len(bts) < 17+n*sha256.Size {
+		return ErrInvalidBatch
+	}
+	proof := make([][]byte, n)
+	for i := range proof {
+		proof[i] = bytes.Clone(bts[17+i*sha256.Size : 17+(i+1)*sha256.Size])
+	}
+	root, err := crypto.UnmarshalSigData(bts[17+n*sha256.Size:], nil)
+	if err != nil {
+		return err
+	}
+	b.Index = binary.BigEndian.Uint64(bts)
+	b.Size = binary.BigEndian.Uint64(bts[8:])
+	b.Proof = proof
+	b.Root = root
+	return nil
+}
+
+// UnmarshalBatchSigData decodes a BatchSigData for data, see crypto.UnmarshalSigData
+func UnmarshalBatchSigData(bts, data []byte) (*BatchSigData, error) {
+	b := &BatchSigData{PublicKey: data}
+	return b, b.UnmarshalBinary(bts)
+}
@@ -0,0 +1,100 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBatchSigData(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	otherPk, _, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	for _, n := range []int{1, 2, 3, 5, 8, 13} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			data := make([][]byte, n)
+			for i := range data {
+				data[i] = []byte(fmt.Sprintf("key %d", i))
+			}
+			batch, err := NewBatchSigData(data, time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+			assert.NoError(t, err)
+			assert.Len(t, batch, n)
+			for i, b := range batch {
+				assert.True(t, b.Verify(sha256.New(), pk))
+				assert.False(t, b.Verify(sha256.New(), otherPk))
+
+				bts, err := b.MarshalBinary()
+				assert.NoError(t, err)
+				parsed, err := UnmarshalBatchSigData(bts, data[i])
+				assert.NoError(t, err)
+				assert.True(t, parsed.Verify(sha256.New(), pk))
+
+				// the proof doesn't hold for other data or positions
+				parsed.PublicKey = data[(i+1)%n]
+				assert.Equal(t, n == 1, parsed.Verify(sha256.New(), pk))
+				parsed.PublicKey = data[i]
+				parsed.Index = uint64((i + 1) % n)
+				assert.Equal(t, n == 1, parsed.Verify(sha256.New(), pk))
+				parsed.Index = uint64(i)
+				parsed.Size++
+				assert.False(t, parsed.Verify(sha256.New(), pk))
+			}
+		})
+	}
+}
+
+func TestKemBatchSigData(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	keys := make([]crypto.KemPublicKey, 4)
+	for i := range keys {
+		keys[i], _, err = WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+		assert.NoError(t, err)
+	}
+	batch, err := NewKemBatchSigData(keys, time.Now(), time.Now().Add(time.Hour), nil, k)
+	assert.NoError(t, err)
+	for i, b := range batch {
+		kb, err := keys[i].MarshalBinary()
+		assert.NoError(t, err)
+		assert.Equal(t, kb, b.PublicKey)
+		assert.True(t, b.Verify(nil, pk))
+		assert.Same(t, batch[0].Root, b.Root)
+	}
+
+	expired, err := NewKemBatchSigData(keys, time.Now().Add(-time.Hour), time.Now().Add(-time.Minute), nil, k)
+	assert.NoError(t, err)
+	assert.False(t, expired[0].Verify(nil, pk))
+
+	_, err = NewKemBatchSigData(nil, time.Now(), time.Now().Add(time.Hour), nil, k)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+	_, err = NewKemBatchSigData([]crypto.KemPublicKey{nil}, time.Now(), time.Now().Add(time.Hour), nil, k)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	_, err = UnmarshalBatchSigData([]byte{0, 1, 2}, nil)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+	_, err = UnmarshalBatchSigData(append(make([]byte, 16), 65), nil)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+}
+
+func BenchmarkBatchSigData(b *testing.B) {
+	_, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := make([][]byte, 1000)
+	for i := range data {
+		data[i] = make([]byte, mlkem768.PublicKeySize)
+		data[i][0], data[i][1] = byte(i), byte(i>>8)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = NewBatchSigData(data, time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	}
+}
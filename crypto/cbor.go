@@ -0,0 +1,155 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// Minimal CBOR (RFC 8949) support for COSE_Key: a single map with integer labels and integer or byte string values,
+// encoded using the core deterministic encoding requirements
+
+var ErrInvalidCBOR = errors.New("invalid CBOR")
+
+const (
+	cborUint  = 0
+	cborNint  = 1
+	cborBytes = 2
+	cborMap   = 5
+)
+
+// cborMapValue is either an int64 or a []byte
+type cborMapValue any
+
+func cborAppendHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major<<5|byte(n))
+	case n <= 0xff:
+		return append(b, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major<<5|27), n)
+	}
+}
+
+func cborAppendInt(b []byte, v int64) []byte {
+	if v < 0 {
+		return cborAppendHead(b, cborNint, uint64(-(v + 1)))
+	}
+	return cborAppendHead(b, cborUint, uint64(v))
+}
+
+// cborMarshalMap encodes m with the keys sorted by their encoded bytes
+func cborMarshalMap(m map[int64]cborMapValue) ([]byte, error) {
+	type entry struct{ k, v []byte }
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		e := entry{k: cborAppendInt(nil, k)}
+		switch tv := v.(type) {
+		case int64:
+			e.v = cborAppendInt(nil, tv)
+		case []byte:
+			e.v = append(cborAppendHead(nil, cborBytes, uint64(len(tv))), tv...)
+		default:
+			return nil, ErrInvalidCBOR
+		}
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return bytes.Compare(a.k, b.k)
+	})
+	out := cborAppendHead(nil, cborMap, uint64(len(entries)))
+	for _, e := range entries {
+		out = append(out, e.k...)
+		out = append(out, e.v...)
+	}
+	return out, nil
+}
+
+func cborReadHead(b []byte) (major byte, n uint64, rest []byte, err error) {
+	if len(b) < 1 {
+		return 0, 0, nil, ErrInvalidCBOR
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), b, nil
+	case info == 24 && len(b) >= 1:
+		return major, uint64(b[0]), b[1:], nil
+	case info == 25 && len(b) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case info == 26 && len(b) >= 4:
+		return major, uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case info == 27 && len(b) >= 8:
+		return major, binary.BigEndian.Uint64(b), b[8:], nil
+	}
+	return 0, 0, nil, ErrInvalidCBOR
+}
+
+func cborReadInt(b []byte) (int64, []byte, error) {
+	major, n, rest, err := cborReadHead(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if n > 1<<63-1 {
+		return 0, nil, ErrInvalidCBOR
+	}
+	switch major {
+	case cborUint:
+		return int64(n), rest, nil
+	case cborNint:
+		return -1 - int64(n), rest, nil
+	}
+	return 0, nil, ErrInvalidCBOR
+}
+
+// cborUnmarshalMap decodes a map encoded by cborMarshalMap, duplicate keys and trailing data are rejected
+func cborUnmarshalMap(b []byte) (map[int64]cborMapValue, error) {
+	major, n, b, err := cborReadHead(b)
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMap || n > uint64(len(b)) {
+		return nil, ErrInvalidCBOR
+	}
+	m := make(map[int64]cborMapValue, n)
+	for range n {
+		var k int64
+		k, b, err = cborReadInt(b)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[k]; ok {
+			return nil, ErrInvalidCBOR
+		}
+		var major byte
+		var l uint64
+		major, l, b, err = cborReadHead(b)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case major == cborUint && l <= 1<<63-1:
+			m[k] = int64(l)
+		case major == cborNint && l <= 1<<63-1:
+			m[k] = -1 - int64(l)
+		case major == cborBytes && l <= uint64(len(b)):
+			m[k] = bytes.Clone(b[:l])
+			b = b[l:]
+		default:
+			return nil, ErrInvalidCBOR
+		}
+	}
+	if len(b) != 0 {
+		return nil, ErrInvalidCBOR
+	}
+	return m, nil
+}
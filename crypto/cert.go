@@ -0,0 +1,467 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// Minimal X.509 v3 certificates (RFC 5280) for ML-KEM / ML-DSA subject keys signed with pure ML-DSA (empty context)
+// as described by the IETF LAMPS drafts, crypto/x509 can't handle these algorithms yet. Only the basic constraints,
+// key usage, subject and authority key identifier extensions are understood, certificates with other critical
+// extensions are rejected when parsed.
+
+const PEMCertificateType = "CERTIFICATE"
+
+// maxCertPathDepth limits the intermediates tried when building a path
+const maxCertPathDepth = 8
+
+var ErrInvalidCertificate = errors.New("invalid certificate")
+var ErrUnhandledCriticalExtension = errors.New("unhandled critical certificate extension")
+var ErrCertSignature = errors.New("certificate signature is invalid")
+var ErrCertNotYetValid = errors.New("certificate is not valid yet")
+var ErrCertExpired = errors.New("certificate has expired")
+var ErrNotCA = errors.New("issuer is not a certificate authority")
+var ErrPathLength = errors.New("certificate path length constraint exceeded")
+var ErrUnknownIssuer = errors.New("certificate signed by unknown authority")
+
+var (
+	oidExtensionSubjectKeyId     = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionAuthorityKeyId   = asn1.ObjectIdentifier{2, 5, 29, 35}
+)
+
+type certificate struct {
+	Raw                asn1.RawContent
+	TBSCertificate     tbsCertificate
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type tbsCertificate struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           certValidity
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	UniqueId           asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueId    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"omitempty,optional,explicit,tag:3"`
+}
+
+type certValidity struct {
+	NotBefore, NotAfter time.Time
+}
+
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+type authorityKeyId struct {
+	Id []byte `asn1:"optional,tag:0"`
+}
+
+// Certificate is a parsed certificate or the template for CreateCertificate, named like x509.Certificate
+type Certificate struct {
+	Raw                     []byte
+	RawTBSCertificate       []byte
+	RawSubjectPublicKeyInfo []byte
+	RawSubject              []byte
+	RawIssuer               []byte
+
+	SerialNumber *big.Int
+	Issuer       pkix.Name
+	Subject      pkix.Name
+	NotBefore    time.Time
+	NotAfter     time.Time
+	// PublicKey is a crypto.KemPublicKey or crypto.SigPublicKey
+	PublicKey          any
+	SignatureAlgorithm asn1.ObjectIdentifier
+	Signature          []byte
+
+	// KeyUsage is 0 when the extension is absent
+	KeyUsage x509.KeyUsage
+	IsCA     bool
+	// MaxPathLen is the number of intermediates allowed below a CA, -1 for no limit
+	MaxPathLen     int
+	SubjectKeyId   []byte
+	AuthorityKeyId []byte
+}
+
+// subjectKeyId is the leftmost 160 bits of the SHA-256 hash of the public key (RFC 7093 method 1)
+func subjectKeyId(spki []byte) ([]byte, error) {
+	var info pkixPublicKey
+	if _, err := asn1.Unmarshal(spki, &info); err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(info.PublicKey.Bytes)
+	return h[:20], nil
+}
+
+func marshalKeyUsage(ku x509.KeyUsage) (pkix.Extension, error) {
+	var bs asn1.BitString
+	for i := 0; i < 9; i++ {
+		if ku&(1<<i) == 0 {
+			continue
+		}
+		if len(bs.Bytes) <= i/8 {
+			bs.Bytes = append(bs.Bytes, 0)
+		}
+		bs.Bytes[i/8] |= 0x80 >> (i % 8)
+		bs.BitLength = i + 1
+	}
+	value, err := asn1.Marshal(bs)
+	return pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value}, err
+}
+
+func certExtensions(template *Certificate, skid, akid []byte) ([]pkix.Extension, error) {
+	var exts []pkix.Extension
+	if template.IsCA {
+		bc := basicConstraints{IsCA: true, MaxPathLen: template.MaxPathLen}
+		if bc.MaxPathLen < 0 {
+			bc.MaxPathLen = -1
+		}
+		value, err := asn1.Marshal(bc)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionBasicConstraints, Critical: true, Value: value})
+	}
+	if template.KeyUsage != 0 {
+		ext, err := marshalKeyUsage(template.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+	value, err := asn1.Marshal(skid)
+	if err != nil {
+		return nil, err
+	}
+	exts = append(exts, pkix.Extension{Id: oidExtensionSubjectKeyId, Value: value})
+	if len(akid) != 0 {
+		value, err := asn1.Marshal(authorityKeyId{Id: akid})
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionAuthorityKeyId, Value: value})
+	}
+	return exts, nil
+}
+
+// CreateCertificate creates a DER certificate for pub (a crypto.KemPublicKey or crypto.SigPublicKey) from template
+// signed by priv, the key of parent. The certificate is self-signed if parent is nil, pub must then be the public key
+// of priv. Only SerialNumber, Subject, NotBefore, NotAfter, KeyUsage, IsCA and MaxPathLen (-1 for no limit) are used
+// from template
+func CreateCertificate(template *Certificate, pub encoding.BinaryMarshaler, parent *Certificate, priv crypto.SigPrivateKey) ([]byte, error) {
+	if pub == nil || priv == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if template.SerialNumber == nil || template.SerialNumber.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: serial number must be positive", ErrInvalidCertificate)
+	}
+	sigOID, err := keyOID(priv)
+	if err != nil {
+		return nil, err
+	}
+	scheme := OIDSigScheme(sigOID)
+	if scheme == nil {
+		return nil, ErrUnknownOID
+	}
+	spki, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	skid, err := subjectKeyId(spki)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := asn1.Marshal(template.Subject.ToRDNSequence())
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, akid := subject, skid
+	if parent == nil {
+		if sigPub, ok := pub.(crypto.SigPublicKey); !ok || !sigPub.Equals(priv.Public()) {
+			return nil, fmt.Errorf("%w: self-signed public key does not match the private key", ErrInvalidCertificate)
+		}
+	} else {
+		if parentPub, ok := parent.PublicKey.(crypto.SigPublicKey); !ok || !parentPub.Equals(priv.Public()) {
+			return nil, fmt.Errorf("%w: private key does not match the parent certificate", ErrInvalidCertificate)
+		}
+		issuer, akid = parent.RawSubject, parent.SubjectKeyId
+		if len(issuer) == 0 {
+			if issuer, err = asn1.Marshal(parent.Subject.ToRDNSequence()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	exts, err := certExtensions(template, skid, akid)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := pkix.AlgorithmIdentifier{Algorithm: sigOID}
+	tbs := tbsCertificate{
+		Version:            2,
+		SerialNumber:       template.SerialNumber,
+		SignatureAlgorithm: algorithm,
+		Issuer:             asn1.RawValue{FullBytes: issuer},
+		Validity: certValidity{
+			NotBefore: template.NotBefore.UTC().Truncate(time.Second),
+			NotAfter:  template.NotAfter.UTC().Truncate(time.Second),
+		},
+		Subject:    asn1.RawValue{FullBytes: subject},
+		PublicKey:  asn1.RawValue{FullBytes: spki},
+		Extensions: exts,
+	}
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := scheme.Sign(priv, tbsDER)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(certificate{
+		TBSCertificate:     tbsCertificate{Raw: tbsDER},
+		SignatureAlgorithm: algorithm,
+		SignatureValue:     asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+}
+
+// ParseCertificate parses a DER certificate
+func ParseCertificate(der []byte) (*Certificate, error) {
+	var cert certificate
+	rest, err := asn1.Unmarshal(der, &cert)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidCertificate)
+	}
+	tbs := &cert.TBSCertificate
+	if tbs.Version != 2 {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidCertificate, tbs.Version+1)
+	}
+	if !tbs.SignatureAlgorithm.Algorithm.Equal(cert.SignatureAlgorithm.Algorithm) ||
+		len(cert.SignatureAlgorithm.Parameters.FullBytes) != 0 || cert.SignatureValue.BitLength%8 != 0 {
+		return nil, fmt.Errorf("%w: signature algorithm", ErrInvalidCertificate)
+	}
+	c := &Certificate{
+		Raw:                     der,
+		RawTBSCertificate:       tbs.Raw,
+		RawSubjectPublicKeyInfo: tbs.PublicKey.FullBytes,
+		RawSubject:              tbs.Subject.FullBytes,
+		RawIssuer:               tbs.Issuer.FullBytes,
+		SerialNumber:            tbs.SerialNumber,
+		NotBefore:               tbs.Validity.NotBefore,
+		NotAfter:                tbs.Validity.NotAfter,
+		SignatureAlgorithm:      cert.SignatureAlgorithm.Algorithm,
+		Signature:               cert.SignatureValue.Bytes,
+		MaxPathLen:              -1,
+	}
+	for _, n := range []struct {
+		raw  []byte
+		name *pkix.Name
+	}{{c.RawSubject, &c.Subject}, {c.RawIssuer, &c.Issuer}} {
+		var rdn pkix.RDNSequence
+		if rest, err := asn1.Unmarshal(n.raw, &rdn); err != nil || len(rest) != 0 {
+			return nil, fmt.Errorf("%w: name", ErrInvalidCertificate)
+		}
+		n.name.FillFromRDNSequence(&rdn)
+	}
+	c.PublicKey, err = ParsePKIXPublicKey(c.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.parseExtensions(tbs.Extensions); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Certificate) parseExtensions(exts []pkix.Extension) error {
+	for _, ext := range exts {
+		var rest []byte
+		var err error
+		switch {
+		case ext.Id.Equal(oidExtensionBasicConstraints):
+			bc := basicConstraints{MaxPathLen: -1}
+			rest, err = asn1.Unmarshal(ext.Value, &bc)
+			c.IsCA, c.MaxPathLen = bc.IsCA, bc.MaxPathLen
+		case ext.Id.Equal(oidExtensionKeyUsage):
+			var bs asn1.BitString
+			rest, err = asn1.Unmarshal(ext.Value, &bs)
+			for i := 0; i < min(bs.BitLength, 9); i++ {
+				if bs.At(i) != 0 {
+					c.KeyUsage |= 1 << i
+				}
+			}
+		case ext.Id.Equal(oidExtensionSubjectKeyId):
+			rest, err = asn1.Unmarshal(ext.Value, &c.SubjectKeyId)
+		case ext.Id.Equal(oidExtensionAuthorityKeyId):
+			var akid authorityKeyId
+			rest, err = asn1.Unmarshal(ext.Value, &akid)
+			c.AuthorityKeyId = akid.Id
+		default:
+			if ext.Critical {
+				return fmt.Errorf("%w: %s", ErrUnhandledCriticalExtension, ext.Id)
+			}
+		}
+		if err != nil || len(rest) != 0 {
+			return fmt.Errorf("%w: extension %s", ErrInvalidCertificate, ext.Id)
+		}
+	}
+	return nil
+}
+
+// CheckSignatureFrom checks the signature of c was made by the key of parent
+func (c *Certificate) CheckSignatureFrom(parent *Certificate) error {
+	key, ok := parent.PublicKey.(crypto.SigPublicKey)
+	if !ok {
+		return crypto.ErrIncompatibleKey
+	}
+	oid, err := keyOID(key)
+	if err != nil {
+		return err
+	}
+	scheme := OIDSigScheme(oid)
+	if scheme == nil || !oid.Equal(c.SignatureAlgorithm) {
+		return ErrCertSignature
+	}
+	valid, err := scheme.Verify(key, c.RawTBSCertificate, c.Signature)
+	if err != nil || !valid {
+		return ErrCertSignature
+	}
+	return nil
+}
+
+// checkTime checks c is valid at t
+func (c *Certificate) checkTime(t time.Time) error {
+	if t.Before(c.NotBefore) {
+		return fmt.Errorf("%w: %s is valid from %s", ErrCertNotYetValid, c.Subject, c.NotBefore.Format(time.RFC3339))
+	}
+	if t.After(c.NotAfter) {
+		return fmt.Errorf("%w: %s expired %s", ErrCertExpired, c.Subject, c.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// VerifyOptions for Certificate.Verify
+type VerifyOptions struct {
+	// Roots are the trust anchors
+	Roots []*Certificate
+	// Intermediates may be used to build the path to a root
+	Intermediates []*Certificate
+	// CurrentTime to check validity at, now if zero
+	CurrentTime time.Time
+}
+
+// Verify builds a path from c through opts.Intermediates to one of opts.Roots, checking signatures, validity,
+// basic constraints and key usage, returning the path starting with c and ending with the root
+func (c *Certificate) Verify(opts VerifyOptions) ([]*Certificate, error) {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if err := c.checkTime(now); err != nil {
+		return nil, err
+	}
+	for _, root := range opts.Roots {
+		if bytes.Equal(root.Raw, c.Raw) {
+			return []*Certificate{c}, nil
+		}
+	}
+	return buildCertPath([]*Certificate{c}, opts, now)
+}
+
+// buildCertPath extends path with issuers of its last certificate until a root is reached
+func buildCertPath(path []*Certificate, opts VerifyOptions, now time.Time) ([]*Certificate, error) {
+	child := path[len(path)-1]
+	var firstErr error
+	try := func(parent *Certificate, root bool) ([]*Certificate, error) {
+		if !bytes.Equal(parent.RawSubject, child.RawIssuer) || slices.Contains(path, parent) {
+			return nil, nil
+		}
+		if len(child.AuthorityKeyId) != 0 && len(parent.SubjectKeyId) != 0 && !bytes.Equal(child.AuthorityKeyId, parent.SubjectKeyId) {
+			return nil, nil
+		}
+		err := checkIssuer(parent, len(path)-1, now)
+		if err == nil {
+			err = child.CheckSignatureFrom(parent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		next := append(slices.Clip(path), parent)
+		if root {
+			return next, nil
+		}
+		if len(path) > maxCertPathDepth {
+			return nil, ErrPathLength
+		}
+		return buildCertPath(next, opts, now)
+	}
+	for i, candidates := range [][]*Certificate{opts.Roots, opts.Intermediates} {
+		for _, parent := range candidates {
+			found, err := try(parent, i == 0)
+			if found != nil {
+				return found, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("%w: %s", ErrUnknownIssuer, child.Issuer)
+	}
+	return nil, firstErr
+}
+
+// checkIssuer checks parent may issue certificates with intermediates below it
+func checkIssuer(parent *Certificate, intermediates int, now time.Time) error {
+	if err := parent.checkTime(now); err != nil {
+		return err
+	}
+	if !parent.IsCA || (parent.KeyUsage != 0 && parent.KeyUsage&x509.KeyUsageCertSign == 0) {
+		return fmt.Errorf("%w: %s", ErrNotCA, parent.Subject)
+	}
+	if parent.MaxPathLen >= 0 && intermediates > parent.MaxPathLen {
+		return fmt.Errorf("%w: %s allows %d intermediates", ErrPathLength, parent.Subject, parent.MaxPathLen)
+	}
+	return nil
+}
+
+// MarshalPEMCertificate encodes a DER certificate as a PEM "CERTIFICATE" block
+func MarshalPEMCertificate(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: PEMCertificateType, Bytes: der})
+}
+
+// ParsePEMCertificate decodes the first PEM block which must be a "CERTIFICATE", see ParseCertificate
+func ParsePEMCertificate(data []byte) (*Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PEMCertificateType {
+		return nil, ErrInvalidPEM
+	}
+	return ParseCertificate(block.Bytes)
+}
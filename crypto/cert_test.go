@@ -0,0 +1,202 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *Certificate
+	key  crypto.SigPrivateKey
+}
+
+func newTestCert(t *testing.T, template *Certificate, pub any, parent *testCA, key crypto.SigPrivateKey) *Certificate {
+	var parentCert *Certificate
+	if parent != nil {
+		parentCert, key = parent.cert, parent.key
+	}
+	der, err := CreateCertificate(template, pub.(interface{ MarshalBinary() ([]byte, error) }), parentCert, key)
+	assert.NoError(t, err)
+	cert, err := ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func newTestCA(t *testing.T, name string, parent *testCA, maxPathLen int) *testCA {
+	pk, k, err := WrapSig(mldsa65.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	template := &Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:         true,
+		MaxPathLen:   maxPathLen,
+	}
+	return &testCA{cert: newTestCert(t, template, pk, parent, k), key: k}
+}
+
+func newTestLeaf(t *testing.T, parent *testCA) *Certificate {
+	pk, _, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	return newTestCert(t, &Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment,
+	}, pk, parent, nil)
+}
+
+func TestCertificate(t *testing.T) {
+	root := newTestCA(t, "root", nil, 1)
+	inter := newTestCA(t, "intermediate", root, 0)
+	leaf := newTestLeaf(t, inter)
+
+	assert.Equal(t, "root", root.cert.Subject.CommonName)
+	assert.Equal(t, "root", root.cert.Issuer.CommonName)
+	assert.True(t, root.cert.IsCA)
+	assert.Equal(t, 1, root.cert.MaxPathLen)
+	assert.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, root.cert.KeyUsage)
+	assert.Equal(t, OIDMLDSA65, root.cert.SignatureAlgorithm)
+	assert.Equal(t, 0, inter.cert.MaxPathLen)
+	assert.Equal(t, "intermediate", leaf.Issuer.CommonName)
+	assert.False(t, leaf.IsCA)
+	assert.Equal(t, -1, leaf.MaxPathLen)
+	assert.Equal(t, x509.KeyUsageKeyEncipherment, leaf.KeyUsage)
+	assert.Equal(t, inter.cert.SubjectKeyId, leaf.AuthorityKeyId)
+	assert.Len(t, leaf.SubjectKeyId, 20)
+	assert.Equal(t, big.NewInt(2), leaf.SerialNumber)
+	_, ok := leaf.PublicKey.(crypto.KemPublicKey)
+	assert.True(t, ok)
+
+	path, err := leaf.Verify(VerifyOptions{Roots: []*Certificate{root.cert}, Intermediates: []*Certificate{inter.cert}})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Certificate{leaf, inter.cert, root.cert}, path)
+	path, err = root.cert.Verify(VerifyOptions{Roots: []*Certificate{root.cert}})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Certificate{root.cert}, path)
+
+	pemCert := MarshalPEMCertificate(leaf.Raw)
+	parsed, err := ParsePEMCertificate(pemCert)
+	assert.NoError(t, err)
+	assert.Equal(t, leaf.Raw, parsed.Raw)
+	_, err = ParsePEMCertificate([]byte("-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----\n"))
+	assert.ErrorIs(t, err, ErrInvalidPEM)
+}
+
+func TestCertificateVerifyErrors(t *testing.T) {
+	root := newTestCA(t, "root", nil, 0)
+	inter := newTestCA(t, "intermediate", root, -1)
+	leaf := newTestLeaf(t, inter)
+	direct := newTestLeaf(t, root)
+	opts := VerifyOptions{Roots: []*Certificate{root.cert}, Intermediates: []*Certificate{inter.cert}}
+
+	// the root allows no intermediates
+	_, err := leaf.Verify(opts)
+	assert.ErrorIs(t, err, ErrPathLength)
+	_, err = direct.Verify(opts)
+	assert.NoError(t, err)
+
+	_, err = direct.Verify(VerifyOptions{})
+	assert.ErrorIs(t, err, ErrUnknownIssuer)
+	_, err = direct.Verify(VerifyOptions{Roots: []*Certificate{root.cert}, CurrentTime: time.Now().Add(2 * time.Hour)})
+	assert.ErrorIs(t, err, ErrCertExpired)
+	_, err = direct.Verify(VerifyOptions{Roots: []*Certificate{root.cert}, CurrentTime: time.Now().Add(-2 * time.Hour)})
+	assert.ErrorIs(t, err, ErrCertNotYetValid)
+
+	// a leaf can't issue certificates
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	signer := &testCA{cert: newTestCert(t, &Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, pk, root, nil), key: k}
+	signed := newTestLeaf(t, signer)
+	_, err = signed.Verify(VerifyOptions{Roots: []*Certificate{root.cert}, Intermediates: []*Certificate{signer.cert}})
+	assert.ErrorIs(t, err, ErrNotCA)
+
+	// a tampered signature
+	tampered, err := ParseCertificate(direct.Raw)
+	assert.NoError(t, err)
+	tampered.Signature[0] ^= 1
+	assert.ErrorIs(t, tampered.CheckSignatureFrom(root.cert), ErrCertSignature)
+	_, err = tampered.Verify(VerifyOptions{Roots: []*Certificate{root.cert}})
+	assert.ErrorIs(t, err, ErrCertSignature)
+}
+
+func TestCreateCertificateErrors(t *testing.T) {
+	root := newTestCA(t, "root", nil, -1)
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	template := &Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+
+	_, err = CreateCertificate(&Certificate{}, pk, nil, k)
+	assert.ErrorIs(t, err, ErrInvalidCertificate)
+	_, err = CreateCertificate(template, pk, root.cert, k)
+	assert.ErrorIs(t, err, ErrInvalidCertificate)
+	_, err = CreateCertificate(template, root.cert.PublicKey.(crypto.SigPublicKey), nil, k)
+	assert.ErrorIs(t, err, ErrInvalidCertificate)
+	_, err = CreateCertificate(template, nil, nil, k)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	_, err = ParseCertificate(append(root.cert.Raw, 0))
+	assert.ErrorIs(t, err, ErrInvalidCertificate)
+}
+
+// readCertFixture reads a PEM certificate from testdata/x509, failing the test if it is missing
+func readCertFixture(t *testing.T, name string) *Certificate {
+	bts, err := os.ReadFile(filepath.Join("testdata", "x509", name))
+	if os.IsNotExist(err) {
+		t.Fatalf("missing fixture %s", name)
+	}
+	assert.NoError(t, err)
+	cert, err := ParsePEMCertificate(bts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return cert
+}
+
+// TestRFC9881Certificates checks the self-signed example certificates from RFC 9881 appendix C
+func TestRFC9881Certificates(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		oid  asn1.ObjectIdentifier
+	}{
+		{"ML-DSA-44", OIDMLDSA44},
+		{"ML-DSA-65", OIDMLDSA65},
+		{"ML-DSA-87", OIDMLDSA87},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cert := readCertFixture(t, "rfc9881-"+strings.ToLower(c.name)+".pem")
+			assert.Equal(t, c.oid, cert.SignatureAlgorithm)
+			key, ok := cert.PublicKey.(crypto.SigPublicKey)
+			if assert.True(t, ok) {
+				assert.Equal(t, c.name, key.Scheme().Name())
+			}
+			assert.True(t, cert.IsCA)
+			assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign|x509.KeyUsageCRLSign, cert.KeyUsage)
+			assert.NoError(t, cert.CheckSignatureFrom(cert))
+			path, err := cert.Verify(VerifyOptions{Roots: []*Certificate{cert}, CurrentTime: cert.NotBefore.Add(time.Hour)})
+			assert.NoError(t, err)
+			assert.Len(t, path, 1)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"time"
+)
+
+// crypto.SigData.Verify always checks the validity window against time.Now, SigDataVerifier checks it against a Clock
+// allowing for skew between the signer and verifier clocks
+
+var ErrSigNotYetValid = errors.New("signature is not valid yet")
+var ErrSigExpired = errors.New("signature has expired")
+var ErrSigInvalid = errors.New("invalid signature")
+
+// Clock returns the current time, time.Now is a Clock
+type Clock func() time.Time
+
+// FixedClock always returns t
+func FixedClock(t time.Time) Clock {
+	return func() time.Time {
+		return t
+	}
+}
+
+// SigDataVerifier verifies crypto.SigData, the zero value is crypto.SigData.Verify with errors
+type SigDataVerifier struct {
+	// Clock is the current time, time.Now if nil
+	Clock Clock
+	// IssueSkew accepts SigData issued up to this long after the current time
+	IssueSkew time.Duration
+	// ExpirySkew accepts SigData up to this long after its ExpiryTime
+	ExpirySkew time.Duration
+}
+
+// Now is the time of the clock
+func (v SigDataVerifier) Now() time.Time {
+	if v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock()
+}
+
+// CheckTime checks now is within the validity window of sigData widened by the skews, returning an error wrapping
+// ErrSigNotYetValid or ErrSigExpired
+func (v SigDataVerifier) CheckTime(sigData *crypto.SigData, now time.Time) error {
+	if now.Add(v.IssueSkew).Before(sigData.IssueTime) {
+		return fmt.Errorf("%w: issued %s", ErrSigNotYetValid, sigData.IssueTime.UTC().Format(time.RFC3339Nano))
+	}
+	if now.Add(-v.ExpirySkew).After(sigData.ExpiryTime) {
+		return fmt.Errorf("%w: expired %s", ErrSigExpired, sigData.ExpiryTime.UTC().Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// sigDataMessage is the message crypto.NewSigData signs, which crypto.SigData does not expose:
+//
+//	h(data) or data if h is nil | issued unix ms | expiry unix ms
+//
+// It is only used for SigData outside its validity window at the real time, see VerifySignature
+func sigDataMessage(sigData *crypto.SigData, h hash.Hash) []byte {
+	var b []byte
+	if h == nil {
+		b = append(b, sigData.PublicKey...)
+	} else {
+		h.Reset()
+		h.Write(sigData.PublicKey)
+		b = h.Sum(b)
+	}
+	b = binary.BigEndian.AppendUint64(b, uint64(sigData.IssueTime.UnixMilli()))
+	return binary.BigEndian.AppendUint64(b, uint64(sigData.ExpiryTime.UnixMilli()))
+}
+
+// VerifySignature checks the hash with CheckSigHash and the signature of sigData made by key, ignoring the validity
+// window, ErrSigInvalid is returned if the signature does not match. Within the window at the real time this is
+// crypto.SigData.Verify, outside it the signed message is rebuilt with sigDataMessage
+func (v SigDataVerifier) VerifySignature(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) error {
+	if key == nil {
+		return crypto.ErrKeyNil
+	}
+	if err := CheckSigHash(h); err != nil {
+		return err
+	}
+	if sigData == nil || sigData.Signature == nil {
+		return ErrSigInvalid
+	}
+	if sigData.Verify(h, key) {
+		return nil
+	}
+	now := time.Now()
+	if !now.Before(sigData.IssueTime) && !now.After(sigData.ExpiryTime) {
+		return ErrSigInvalid
+	}
+	ok, err := key.Scheme().Verify(key, sigDataMessage(sigData, h), sigData.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSigInvalid
+	}
+	return nil
+}
+
+// Verify checks the validity window against the clock with CheckTime then the signature with VerifySignature
+func (v SigDataVerifier) Verify(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) error {
+	if sigData == nil {
+		return ErrSigInvalid
+	}
+	if err := v.CheckTime(sigData, v.Now()); err != nil {
+		return err
+	}
+	return v.VerifySignature(sigData, h, key)
+}
@@ -0,0 +1,116 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"hash"
+	"testing"
+	"time"
+)
+
+func TestSigDataVerifier(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	issue := time.UnixMilli(1750000000000)
+	expiry := issue.Add(time.Hour)
+	data := []byte("kem public key")
+	for _, h := range []hash.Hash{sha256.New(), nil} {
+		sd, err := NewSigData(data, issue, expiry, h, k)
+		assert.NoError(t, err)
+
+		assert.NoError(t, SigDataVerifier{Clock: FixedClock(issue)}.Verify(sd, h, pk))
+		assert.NoError(t, SigDataVerifier{Clock: FixedClock(expiry)}.Verify(sd, h, pk))
+		assert.ErrorIs(t, SigDataVerifier{Clock: FixedClock(issue.Add(-time.Millisecond))}.Verify(sd, h, pk), ErrSigNotYetValid)
+		assert.ErrorIs(t, SigDataVerifier{Clock: FixedClock(expiry.Add(time.Millisecond))}.Verify(sd, h, pk), ErrSigExpired)
+
+		skewed := SigDataVerifier{IssueSkew: time.Minute, ExpirySkew: 2 * time.Minute}
+		skewed.Clock = FixedClock(issue.Add(-time.Minute))
+		assert.NoError(t, skewed.Verify(sd, h, pk))
+		skewed.Clock = FixedClock(issue.Add(-time.Minute - time.Millisecond))
+		assert.ErrorIs(t, skewed.Verify(sd, h, pk), ErrSigNotYetValid)
+		skewed.Clock = FixedClock(expiry.Add(2 * time.Minute))
+		assert.NoError(t, skewed.Verify(sd, h, pk))
+		skewed.Clock = FixedClock(expiry.Add(2*time.Minute + time.Millisecond))
+		assert.ErrorIs(t, skewed.Verify(sd, h, pk), ErrSigExpired)
+
+		damaged := *sd
+		damaged.ExpiryTime = damaged.ExpiryTime.Add(time.Minute)
+		assert.ErrorIs(t, SigDataVerifier{Clock: FixedClock(issue)}.Verify(&damaged, h, pk), ErrSigInvalid)
+		damaged = *sd
+		damaged.PublicKey = []byte("other key")
+		assert.ErrorIs(t, SigDataVerifier{Clock: FixedClock(issue)}.Verify(&damaged, h, pk), ErrSigInvalid)
+	}
+
+	_, other, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	sd, err := NewSigData(data, issue, expiry, nil, other)
+	assert.NoError(t, err)
+	v := SigDataVerifier{Clock: FixedClock(issue)}
+	assert.ErrorIs(t, v.Verify(sd, nil, pk), ErrSigInvalid)
+	assert.ErrorIs(t, v.Verify(sd, nil, nil), crypto.ErrKeyNil)
+	assert.ErrorIs(t, v.Verify(nil, nil, pk), ErrSigInvalid)
+}
+
+// TestSigDataVerifierMatchesSigData checks VerifySignature accepts SigData from crypto.NewSigData inside, before and
+// after the validity window, the latter two use the rebuilt signed message
+func TestSigDataVerifierMatchesSigData(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	now := time.Now()
+	windows := []struct {
+		name          string
+		issue, expiry time.Time
+	}{
+		{"Valid", now.Add(-time.Minute), now.Add(time.Hour)},
+		{"Not yet valid", now.Add(time.Hour), now.Add(2 * time.Hour)},
+		{"Expired", now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+	}
+	for _, w := range windows {
+		for _, h := range []hash.Hash{sha256.New(), nil} {
+			sd := crypto.NewSigData([]byte("kem public key"), w.issue, w.expiry, h, k)
+			assert.NotNil(t, sd, w.name)
+			assert.Equal(t, w.name == "Valid", sd.Verify(h, pk), w.name)
+			assert.NoError(t, SigDataVerifier{}.VerifySignature(sd, h, pk), w.name)
+
+			damaged := *sd
+			damaged.ExpiryTime = damaged.ExpiryTime.Add(time.Millisecond)
+			assert.ErrorIs(t, SigDataVerifier{}.VerifySignature(&damaged, h, pk), ErrSigInvalid, w.name)
+		}
+	}
+}
+
+// TestSigDataMessage checks sigDataMessage rebuilds the message crypto.NewSigData signs, it fails if the upstream
+// format changes
+func TestSigDataMessage(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	issue := time.UnixMilli(1750000000000)
+	for _, h := range []hash.Hash{sha256.New(), nil} {
+		sd := crypto.NewSigData([]byte("kem public key"), issue, issue.Add(time.Hour), h, k)
+		assert.NotNil(t, sd)
+		ok, err := scheme.Verify(pk, sigDataMessage(sd, h), sd.Signature)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+}
+
+func TestSigDataVerifierFIPSHash(t *testing.T) {
+	setFIPSMode(t, false)
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	issue := time.UnixMilli(1750000000000)
+	sd := crypto.NewSigData([]byte("kem public key"), issue, issue.Add(time.Hour), sha1.New(), k)
+	v := SigDataVerifier{Clock: FixedClock(issue)}
+	assert.NoError(t, v.Verify(sd, sha1.New(), pk))
+	SetFIPSMode(true)
+	assert.ErrorIs(t, v.Verify(sd, sha1.New(), pk), ErrHashNotApproved)
+}
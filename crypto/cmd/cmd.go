@@ -0,0 +1,206 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables the passphrase for sealed keys is read from, the file descriptor takes priority
+const (
+	PassphraseEnv   = "PQC_PASSPHRASE"
+	PassphraseFdEnv = "PQC_PASSPHRASE_FD"
+)
+
+// PolicyEnv names the policy (see pqc_crypto.PolicyByName) schemes are checked against, "default" if unset
+const PolicyEnv = "PQC_POLICY"
+
+// sealedKeyUsage is the usage line for commands reading private keys with readPrivateKey
+const sealedKeyUsage = "Private keys may be sealed, the passphrase is read from the file descriptor in " + PassphraseFdEnv + " or from " + PassphraseEnv
+
+var ErrDoubleStdin = errors.New("stdin can only be used once")
+var ErrNoPassphrase = errors.New("no passphrase, set " + PassphraseEnv + " or " + PassphraseFdEnv)
+
+// tool holds the build information and streams shared by the commands in this package
+type tool struct {
+	buildName    string
+	buildDate    string
+	buildVersion string
+	buildAuthor  string
+	buildLicense string
+	exit         func(code int)
+	stdout       *os.File
+	stdin        *os.File
+	stdinUsed    bool
+}
+
+func newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) *tool {
+	if exit == nil {
+		exit = os.Exit
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	return &tool{
+		buildName:    buildName,
+		buildDate:    buildDate,
+		buildVersion: buildVersion,
+		buildAuthor:  buildAuthor,
+		buildLicense: buildLicense,
+		exit:         exit,
+		stdout:       stdout,
+		stdin:        stdin,
+	}
+}
+
+// usage prints the build information and usage lines to stderr then exits with code 1
+func (t *tool) usage(lines ...string) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s %s (%s) - (C) %s - %s\n\nUsage:\n", t.buildName, t.buildVersion, t.buildDate, t.buildAuthor, t.buildLicense)
+	for _, l := range lines {
+		_, _ = fmt.Fprintln(os.Stderr, "  "+l)
+	}
+	_, _ = fmt.Fprintln(os.Stderr, "\nUse - in place of a file for stdin / stdout")
+	t.exit(1)
+}
+
+// fail prints err to stderr then exits with code 2
+func (t *tool) fail(err error) {
+	_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+	t.exit(2)
+}
+
+// read the file at path or stdin for -
+func (t *tool) read(path string) ([]byte, error) {
+	if path != "-" {
+		return os.ReadFile(path)
+	}
+	if t.stdinUsed {
+		return nil, ErrDoubleStdin
+	}
+	t.stdinUsed = true
+	return io.ReadAll(t.stdin)
+}
+
+// write data to the file at path with perm or stdout for -
+func (t *tool) write(path string, data []byte, perm os.FileMode) error {
+	if path != "-" {
+		return os.WriteFile(path, data, perm)
+	}
+	_, err := t.stdout.Write(data)
+	if err != nil {
+		return err
+	}
+	return t.stdout.Sync()
+}
+
+// passphrase reads the passphrase from the file descriptor in PQC_PASSPHRASE_FD or from PQC_PASSPHRASE, a single
+// trailing newline is removed
+func passphrase() ([]byte, error) {
+	if fdStr, ok := os.LookupEnv(PassphraseFdEnv); ok {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid %s: %q", PassphraseFdEnv, fdStr)
+		}
+		f := os.NewFile(uintptr(fd), "passphrase")
+		if f == nil {
+			return nil, fmt.Errorf("invalid %s: %q", PassphraseFdEnv, fdStr)
+		}
+		defer func() { _ = f.Close() }()
+		p, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return trimNewline(p), nil
+	}
+	if p, ok := os.LookupEnv(PassphraseEnv); ok {
+		return trimNewline([]byte(p)), nil
+	}
+	return nil, ErrNoPassphrase
+}
+
+// readPrivateKey reads a raw private key with unmarshal, or a sealed private key (see MainSealKem / MainSealSig) with
+// open using the passphrase, a sealed key must be of the scheme named
+func (t *tool) readPrivateKey(path, name string, unmarshal func([]byte) (encoding.BinaryMarshaler, error), open func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error)) (encoding.BinaryMarshaler, error) {
+	bts, err := t.read(path)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(bts)
+	if _, _, err := pqc_crypto.ParseSealedKeyHeader(bts); errors.Is(err, pqc_crypto.ErrInvalidSealedKey) {
+		return unmarshal(bts)
+	} else if err != nil {
+		return nil, err
+	}
+	pass, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := open(bts, pass)
+	if err != nil {
+		return nil, err
+	}
+	if keyName, err := pqc_crypto.KeySchemeName(key); err != nil || keyName != name {
+		return nil, ErrSchemeMismatch
+	}
+	return key, nil
+}
+
+// readSigPrivateKey is readPrivateKey for a raw or sealed signature private key of scheme
+func (t *tool) readSigPrivateKey(scheme *pqc_crypto.SigWrapper, path string) (crypto.SigPrivateKey, error) {
+	key, err := t.readPrivateKey(path, scheme.Name(), func(b []byte) (encoding.BinaryMarshaler, error) {
+		return scheme.UnmarshalBinaryPrivateKey(b)
+	}, func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error) {
+		return pqc_crypto.OpenSigPrivateKey(sealed, passphrase)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key.(crypto.SigPrivateKey), nil
+}
+
+func trimNewline(p []byte) []byte {
+	p = bytes.TrimSuffix(p, []byte("\n"))
+	return bytes.TrimSuffix(p, []byte("\r"))
+}
+
+// checkPolicy runs check with the policy named by PQC_POLICY
+func checkPolicy(check func(p pqc_crypto.Policy) error) error {
+	name := os.Getenv(PolicyEnv)
+	if name == "" {
+		name = "default"
+	}
+	p, ok := pqc_crypto.PolicyByName(name)
+	if !ok {
+		return fmt.Errorf("unknown %s: %q", PolicyEnv, name)
+	}
+	return check(p)
+}
+
+// isCommand checks if arg is one of the (case-insensitive) aliases
+func isCommand(arg string, aliases ...string) bool {
+	for _, a := range aliases {
+		if strings.EqualFold(arg, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// args returns os.Args after the command name, padded with empty strings to at least n
+func args(n int) []string {
+	a := make([]string, max(n, len(os.Args)-1))
+	copy(a, os.Args[1:])
+	return a
+}
@@ -0,0 +1,129 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"os"
+	"strings"
+	"time"
+)
+
+// MainMultiSig creates multi-signature containers for raw binary KEM public keys and adds signatures to them with raw
+// binary private keys of scheme, the data is signed with SHA-256
+func MainMultiSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainMultiSig(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainMultiSig is MainMultiSig with a custom exit and standard streams
+func TestingMainMultiSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	t := newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin)
+	a := args(4)
+	if a[1] == "" || a[2] == "" {
+		a[0] = ""
+	}
+	var command func(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error
+	switch {
+	case isCommand(a[0], "c", "create") && a[3] != "":
+		command = multiSigCreate
+	case isCommand(a[0], "s", "sign"):
+		command = multiSigSign
+	case isCommand(a[0], "i", "inspect"):
+		command = multiSigInspect
+	default:
+		t.usage(
+			"(c)reate <kem public key> <container> <valid until>",
+			"(s)ign <private key> <container>",
+			"(i)nspect <container> <container text>",
+			"",
+			"The validity end is RFC 3339, the container is valid from when it is created",
+			"Signing adds a signature to the container, replacing any earlier signature by the same key",
+			sealedKeyUsage,
+			"The scheme is checked against the "+PolicyEnv+" policy: default, fips, cnsa2 or none",
+			"Scheme: "+scheme.Name(),
+		)
+		return
+	}
+	err := checkPolicy(func(p pqc_crypto.Policy) error {
+		return p.CheckSig(scheme)
+	})
+	if err == nil {
+		err = command(t, scheme, a[1:])
+	}
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	t.exit(0)
+}
+
+func readMultiSig(t *tool, path string) (*pqc_crypto.MultiSigData, error) {
+	bts, err := t.read(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &pqc_crypto.MultiSigData{}
+	return m, m.UnmarshalBinary(bts)
+}
+
+func writeMultiSig(t *tool, path string, m *pqc_crypto.MultiSigData) error {
+	bts, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return t.write(path, bts, 0644)
+}
+
+func multiSigCreate(t *tool, _ *pqc_crypto.SigWrapper, a []string) error {
+	key, err := t.read(a[0])
+	if err != nil {
+		return err
+	}
+	expiry, err := time.Parse(time.RFC3339, a[2])
+	if err != nil {
+		return err
+	}
+	return writeMultiSig(t, a[1], pqc_crypto.NewMultiSigData(key, time.Now(), expiry))
+}
+
+func multiSigSign(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error {
+	key, err := t.readSigPrivateKey(scheme, a[0])
+	if err != nil {
+		return err
+	}
+	m, err := readMultiSig(t, a[1])
+	if err != nil {
+		return err
+	}
+	if err := m.Sign(sha256.New(), key); err != nil {
+		return err
+	}
+	return writeMultiSig(t, a[1], m)
+}
+
+func multiSigInspect(t *tool, _ *pqc_crypto.SigWrapper, a []string) error {
+	m, err := readMultiSig(t, a[0])
+	if err != nil {
+		return err
+	}
+	keyHash := sha256.Sum256(m.PublicKey)
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "Key SHA-256: %s\nIssued: %s\nExpires: %s\nSignatures: %d\n",
+		pqc_crypto.FingerprintHex(keyHash[:]), m.IssueTime.UTC().Format(time.RFC3339), m.ExpiryTime.UTC().Format(time.RFC3339), len(m.Signatures))
+	for _, s := range m.Signatures {
+		valid := "invalid"
+		if len(m.ValidSigners(sha256.New(), []crypto.SigPublicKey{s.Signer})) == 1 {
+			valid = "valid"
+		}
+		name, _ := pqc_crypto.KeySchemeName(s.Signer)
+		id := ""
+		if k, ok := s.Signer.(*pqc_crypto.SigPublicKeyWrapper); ok {
+			id = k.KeyID()
+		}
+		_, _ = fmt.Fprintf(&b, "%s %s %s\n", name, id, valid)
+	}
+	return t.write(a[1], []byte(b.String()), 0644)
+}
@@ -0,0 +1,91 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMainMultiSig(t *testing.T) {
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapSig(mldsa44.Scheme())
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	kemBts, err := kemPk.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/kem.pub", kemBts, 0644))
+	pks := make([]crypto.SigPublicKey, 2)
+	for i, name := range []string{"/op1", "/op2"} {
+		pk, k, err := scheme.GenerateKeyPair()
+		assert.NoError(t, err)
+		pks[i] = pk
+		bts, err := k.MarshalBinary()
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+name, bts, 0600))
+		sealed, err := pqc_crypto.SealPrivateKey(k, []byte("correct horse"), pqc_crypto.ScryptParams{LogN: 10, R: 8, P: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+name+".sealed", sealed, 0600))
+	}
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainMultiSig(scheme, "a", "b", "c", "d", "e", exitCode(t, code), nil, nil)
+	}
+	parse := func(t *testing.T) *pqc_crypto.MultiSigData {
+		bts, err := os.ReadFile(dir + "/container")
+		assert.NoError(t, err)
+		m := &pqc_crypto.MultiSigData{}
+		assert.NoError(t, m.UnmarshalBinary(bts))
+		return m
+	}
+
+	run(t, 0, "create", dir+"/kem.pub", dir+"/container", time.Now().Add(time.Hour).Format(time.RFC3339))
+	m := parse(t)
+	assert.Equal(t, kemBts, m.PublicKey)
+	assert.Empty(t, m.Signatures)
+
+	run(t, 0, "s", dir+"/op1", dir+"/container")
+	assert.True(t, parse(t).Verify(sha256.New(), pks, 1))
+	assert.False(t, parse(t).Verify(sha256.New(), pks, 2))
+	run(t, 0, "sign", dir+"/op2", dir+"/container")
+	t.Run("sealed key", func(t *testing.T) {
+		run(t, 2, "sign", dir+"/op2.sealed", dir+"/container")
+		t.Setenv(PassphraseEnv, "wrong")
+		run(t, 2, "sign", dir+"/op2.sealed", dir+"/container")
+		t.Setenv(PassphraseEnv, "correct horse")
+		run(t, 0, "sign", dir+"/op2.sealed", dir+"/container")
+	})
+	m = parse(t)
+	assert.Len(t, m.Signatures, 2)
+	assert.True(t, m.Verify(sha256.New(), pks, 2))
+
+	run(t, 0, "i", dir+"/container", dir+"/container.txt")
+	text, err := os.ReadFile(dir + "/container.txt")
+	assert.NoError(t, err)
+	assert.Contains(t, string(text), "Signatures: 2\n")
+	assert.Contains(t, string(text), "ML-DSA-44 "+pks[1].(*pqc_crypto.SigPublicKeyWrapper).KeyID()+" valid\n")
+	assert.Equal(t, 2, strings.Count(string(text), " valid\n"))
+
+	t.Run("errors", func(t *testing.T) {
+		run(t, 2, "create", dir+"/kem.pub", dir+"/bad", "tomorrow")
+		run(t, 2, "sign", dir+"/op1", dir+"/kem.pub")
+		run(t, 2, "sign", dir+"/kem.pub", dir+"/container")
+	})
+	t.Run("usage", func(t *testing.T) {
+		run(t, 1)
+		run(t, 1, "create", dir+"/kem.pub", dir+"/container")
+		run(t, 1, "sign", dir+"/op1")
+	})
+}
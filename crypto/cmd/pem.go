@@ -0,0 +1,227 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"encoding"
+	"errors"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"os"
+)
+
+var ErrSchemeMismatch = errors.New("key scheme does not match")
+
+// pemScheme is the part of KemWrapper / SigWrapper needed to convert keys
+type pemScheme struct {
+	name             string
+	check            func(p pqc_crypto.Policy) error
+	unmarshalPublic  func([]byte) (encoding.BinaryMarshaler, error)
+	unmarshalPrivate func([]byte) (encoding.BinaryMarshaler, error)
+	open             func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error)
+	derive           func([]byte) (encoding.BinaryMarshaler, error)
+}
+
+// MainPEMKem converts raw binary KEM keys of scheme to and from PEM encoded SubjectPublicKeyInfo / PKCS#8
+func MainPEMKem(scheme *pqc_crypto.KemWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainPEMKem(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainPEMKem is MainPEMKem with a custom exit and standard streams
+func TestingMainPEMKem(scheme *pqc_crypto.KemWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	pemMain(newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin), pemScheme{
+		name: scheme.Name(),
+		check: func(p pqc_crypto.Policy) error {
+			return p.CheckKem(scheme)
+		},
+		unmarshalPublic: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPublicKey(b)
+		},
+		unmarshalPrivate: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPrivateKey(b)
+		},
+		open: func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error) {
+			return pqc_crypto.OpenKemPrivateKey(sealed, passphrase)
+		},
+		derive: func(b []byte) (encoding.BinaryMarshaler, error) {
+			_, k, err := scheme.DeriveKeyPair(b)
+			return k, err
+		},
+	})
+}
+
+// MainPEMSig converts raw binary signature keys of scheme to and from PEM encoded SubjectPublicKeyInfo / PKCS#8
+func MainPEMSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainPEMSig(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainPEMSig is MainPEMSig with a custom exit and standard streams
+func TestingMainPEMSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	pemMain(newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin), pemScheme{
+		name: scheme.Name(),
+		check: func(p pqc_crypto.Policy) error {
+			return p.CheckSig(scheme)
+		},
+		unmarshalPublic: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPublicKey(b)
+		},
+		unmarshalPrivate: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPrivateKey(b)
+		},
+		open: func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error) {
+			return pqc_crypto.OpenSigPrivateKey(sealed, passphrase)
+		},
+		derive: func(b []byte) (encoding.BinaryMarshaler, error) {
+			_, k, err := scheme.DeriveKeyPair(b)
+			return k, err
+		},
+	})
+}
+
+func pemMain(t *tool, scheme pemScheme) {
+	a := args(3)
+	if a[1] == "" || a[2] == "" {
+		a[0] = ""
+	}
+	var command func(t *tool, scheme pemScheme, in, out string) error
+	switch {
+	case isCommand(a[0], "p", "pub", "public"):
+		command = pemPublic
+	case isCommand(a[0], "k", "priv", "private"):
+		command = pemPrivate
+	case isCommand(a[0], "s", "seed"):
+		command = pemSeed
+	case isCommand(a[0], "r", "raw"):
+		command = pemRaw
+	case isCommand(a[0], "f", "fingerprint"):
+		command = pemFingerprint
+	default:
+		t.usage(
+			"(p)ub(lic) <raw public key> <pem public key>",
+			"priv(ate) | k <raw private key> <pem private key>",
+			"(s)eed <raw seed> <pem private key>",
+			"(r)aw <pem key> <raw key>",
+			"(f)ingerprint <raw or pem key> <fingerprint text>",
+			"",
+			sealedKeyUsage,
+			"The scheme is checked against the "+PolicyEnv+" policy: default, fips, cnsa2 or none",
+			"Scheme: "+scheme.name,
+		)
+		return
+	}
+	err := checkPolicy(scheme.check)
+	if err == nil {
+		err = command(t, scheme, a[1], a[2])
+	}
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	t.exit(0)
+}
+
+func pemPublic(t *tool, scheme pemScheme, in, out string) error {
+	bts, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	key, err := scheme.unmarshalPublic(bts)
+	if err != nil {
+		return err
+	}
+	p, err := pqc_crypto.MarshalPEMPublicKey(key)
+	if err != nil {
+		return err
+	}
+	return t.write(out, p, 0644)
+}
+
+func pemPrivate(t *tool, scheme pemScheme, in, out string) error {
+	key, err := t.readPrivateKey(in, scheme.name, scheme.unmarshalPrivate, scheme.open)
+	if err != nil {
+		return err
+	}
+	p, err := pqc_crypto.MarshalPEMPrivateKey(key, nil, pqc_crypto.PrivateKeyExpanded)
+	if err != nil {
+		return err
+	}
+	return t.write(out, p, 0600)
+}
+
+func pemSeed(t *tool, scheme pemScheme, in, out string) error {
+	seed, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	key, err := scheme.derive(seed)
+	if err != nil {
+		return err
+	}
+	p, err := pqc_crypto.MarshalPEMPrivateKey(key, seed, pqc_crypto.PrivateKeySeed)
+	if err != nil {
+		return err
+	}
+	return t.write(out, p, 0600)
+}
+
+// parsePEMKey parses a PEM public or private key of scheme, returning if it is private
+func parsePEMKey(scheme pemScheme, bts []byte) (encoding.BinaryMarshaler, bool, error) {
+	private := false
+	key, err := pqc_crypto.ParsePEMPublicKey(bts)
+	if errors.Is(err, pqc_crypto.ErrInvalidPEM) {
+		private = true
+		key, _, err = pqc_crypto.ParsePEMPrivateKey(bts)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	bKey, ok := key.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, false, ErrSchemeMismatch
+	}
+	if name, err := pqc_crypto.KeySchemeName(bKey); err != nil || name != scheme.name {
+		return nil, false, ErrSchemeMismatch
+	}
+	return bKey, private, nil
+}
+
+func pemRaw(t *tool, scheme pemScheme, in, out string) error {
+	bts, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	bKey, private, err := parsePEMKey(scheme, bts)
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if private {
+		perm = 0600
+	}
+	raw, err := bKey.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return t.write(out, raw, perm)
+}
+
+func pemFingerprint(t *tool, scheme pemScheme, in, out string) error {
+	bts, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	key, _, err := parsePEMKey(scheme, bts)
+	if err != nil {
+		key, err = scheme.unmarshalPublic(bts)
+	}
+	if err != nil {
+		key, err = scheme.unmarshalPrivate(bts)
+	}
+	if err != nil {
+		return err
+	}
+	text, err := pqc_crypto.FingerprintText(key)
+	if err != nil {
+		return err
+	}
+	return t.write(out, []byte(text), 0644)
+}
@@ -0,0 +1,170 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func exitCode(t *testing.T, want int) func(code int) {
+	return func(code int) {
+		if code != want {
+			t.Log(code)
+			t.FailNow()
+		}
+	}
+}
+
+func TestMainPEMKem(t *testing.T) {
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapKem(mlkem768.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	pkBts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+	kBts, err := k.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/pubkey", pkBts, 0644))
+	assert.NoError(t, os.WriteFile(dir+"/privkey", kBts, 0600))
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, stdout, stdin *os.File, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainPEMKem(scheme, "a", "b", "c", "d", "e", exitCode(t, code), stdout, stdin)
+	}
+
+	t.Run("public file file", func(t *testing.T) {
+		run(t, 0, nil, nil, "pub", dir+"/pubkey", dir+"/pubkey.pem")
+		bts, err := os.ReadFile(dir + "/pubkey.pem")
+		assert.NoError(t, err)
+		rpk, err := pqc_crypto.ParsePEMPublicKey(bts)
+		assert.NoError(t, err)
+		assert.True(t, pk.Equals(rpk.(crypto.KemPublicKey)))
+	})
+	t.Run("raw public stdin stdout", func(t *testing.T) {
+		bts, err := os.ReadFile(dir + "/pubkey.pem")
+		assert.NoError(t, err)
+		assert.NoError(t, writeStdIn(dir, bts))
+		run(t, 0, getStdOut(dir), getStdIn(dir), "R", "-", "-")
+		assert.True(t, checkStdOut(dir, pkBts))
+	})
+	t.Run("private file stdout", func(t *testing.T) {
+		run(t, 0, getStdOut(dir), nil, "private", dir+"/privkey", "-")
+		bts, err := os.ReadFile(dir + "/stdout")
+		assert.NoError(t, err)
+		rk, seed, err := pqc_crypto.ParsePEMPrivateKey(bts)
+		assert.NoError(t, err)
+		assert.Nil(t, seed)
+		assert.True(t, k.Equals(rk.(crypto.KemPrivateKey)))
+	})
+	t.Run("sealed private", func(t *testing.T) {
+		sealed, err := pqc_crypto.SealPrivateKey(k, []byte("correct horse"), pqc_crypto.ScryptParams{LogN: 10, R: 8, P: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+"/privkey.sealed", sealed, 0600))
+		run(t, 2, nil, nil, "private", dir+"/privkey.sealed", dir+"/sealed.pem")
+		t.Setenv(PassphraseEnv, "correct horse")
+		run(t, 0, nil, nil, "private", dir+"/privkey.sealed", dir+"/sealed.pem")
+		bts, err := os.ReadFile(dir + "/sealed.pem")
+		assert.NoError(t, err)
+		rk, _, err := pqc_crypto.ParsePEMPrivateKey(bts)
+		assert.NoError(t, err)
+		assert.True(t, k.Equals(rk.(crypto.KemPrivateKey)))
+
+		_, other, err := pqc_crypto.WrapKem(mlkem1024.Scheme()).GenerateKeyPair()
+		assert.NoError(t, err)
+		sealed, err = pqc_crypto.SealPrivateKey(other, []byte("correct horse"), pqc_crypto.ScryptParams{LogN: 10, R: 8, P: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+"/other.sealed", sealed, 0600))
+		run(t, 2, nil, nil, "private", dir+"/other.sealed", dir+"/other.pem")
+	})
+	t.Run("seed stdin file", func(t *testing.T) {
+		seed := make([]byte, scheme.SeedSize())
+		assert.NoError(t, writeStdIn(dir, seed))
+		run(t, 0, nil, getStdIn(dir), "s", "-", dir+"/seed.pem")
+		run(t, 0, nil, nil, "raw", dir+"/seed.pem", dir+"/seed.raw")
+		bts, err := os.ReadFile(dir + "/seed.raw")
+		assert.NoError(t, err)
+		_, sk, err := scheme.DeriveKeyPair(seed)
+		assert.NoError(t, err)
+		skBts, err := sk.MarshalBinary()
+		assert.NoError(t, err)
+		assert.Equal(t, skBts, bts)
+	})
+	t.Run("raw wrong scheme", func(t *testing.T) {
+		_, k512, err := pqc_crypto.WrapKem(mlkem512.Scheme()).GenerateKeyPair()
+		assert.NoError(t, err)
+		bts, err := pqc_crypto.MarshalPEMPrivateKey(k512, nil, pqc_crypto.PrivateKeyExpanded)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+"/k512.pem", bts, 0600))
+		run(t, 2, nil, nil, "raw", dir+"/k512.pem", dir+"/k512.raw")
+	})
+	t.Run("fingerprint", func(t *testing.T) {
+		want, err := pqc_crypto.FingerprintText(pk)
+		assert.NoError(t, err)
+		for _, in := range []string{"/pubkey", "/privkey", "/pubkey.pem"} {
+			run(t, 0, getStdOut(dir), nil, "f", dir+in, "-")
+			assert.True(t, checkStdOut(dir, []byte(want)))
+		}
+		run(t, 2, nil, nil, "fingerprint", dir+"/k512.pem", dir+"/k512.txt")
+	})
+	t.Run("invalid key", func(t *testing.T) {
+		run(t, 2, nil, nil, "pub", dir+"/privkey", dir+"/invalid.pem")
+	})
+	t.Run("policy", func(t *testing.T) {
+		t.Setenv(PolicyEnv, "cnsa2")
+		run(t, 2, nil, nil, "pub", dir+"/pubkey", dir+"/cnsa2.pem")
+		_, err := os.Stat(dir + "/cnsa2.pem")
+		assert.True(t, os.IsNotExist(err))
+		t.Setenv(PolicyEnv, "fips")
+		run(t, 0, nil, nil, "pub", dir+"/pubkey", dir+"/fips.pem")
+		t.Setenv(PolicyEnv, "bogus")
+		run(t, 2, nil, nil, "pub", dir+"/pubkey", dir+"/bogus.pem")
+	})
+	t.Run("usage", func(t *testing.T) {
+		run(t, 1, nil, nil)
+		run(t, 1, nil, nil, "pub", dir+"/pubkey")
+		run(t, 1, nil, nil, "abc", dir+"/pubkey", dir+"/pubkey.pem")
+	})
+}
+
+func TestMainPEMSig(t *testing.T) {
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	pkBts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+	kBts, err := k.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/pubkey", pkBts, 0644))
+	assert.NoError(t, os.WriteFile(dir+"/privkey", kBts, 0600))
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, stdout, stdin *os.File, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainPEMSig(scheme, "a", "b", "c", "d", "e", exitCode(t, code), stdout, stdin)
+	}
+	run(t, 0, nil, nil, "p", dir+"/pubkey", dir+"/pubkey.pem")
+	run(t, 0, nil, nil, "k", dir+"/privkey", dir+"/privkey.pem")
+	run(t, 0, nil, nil, "r", dir+"/pubkey.pem", dir+"/pubkey.raw")
+	run(t, 0, nil, nil, "r", dir+"/privkey.pem", dir+"/privkey.raw")
+	bts, err := os.ReadFile(dir + "/pubkey.raw")
+	assert.NoError(t, err)
+	assert.Equal(t, pkBts, bts)
+	bts, err = os.ReadFile(dir + "/privkey.raw")
+	assert.NoError(t, err)
+	assert.Equal(t, kBts, bts)
+	run(t, 1, nil, nil, "p")
+}
@@ -0,0 +1,170 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/1f349/pqc-handshake/crypto/trust"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFingerprint = errors.New("invalid fingerprint")
+
+// MainRevocation creates, updates and inspects revocation lists signed by a raw binary root key of scheme
+func MainRevocation(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainRevocation(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainRevocation is MainRevocation with a custom exit and standard streams
+func TestingMainRevocation(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	t := newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin)
+	a := args(5)
+	if a[1] == "" || a[2] == "" {
+		a[0] = ""
+	}
+	var command func(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error
+	switch {
+	case isCommand(a[0], "c", "create"):
+		command = revocationCreate
+	case isCommand(a[0], "r", "revoke") && a[3] != "":
+		command = revocationRevoke
+	case isCommand(a[0], "u", "unrevoke") && a[3] != "":
+		command = revocationUnrevoke
+	case isCommand(a[0], "i", "inspect") && a[3] != "":
+		command = revocationInspect
+	default:
+		t.usage(
+			"(c)reate <root private key> <revocation list>",
+			"(r)evoke <root private key> <revocation list> <fingerprint> [revocation time]",
+			"(u)nrevoke <root private key> <revocation list> <fingerprint>",
+			"(i)nspect <root public key> <revocation list> <list text>",
+			"",
+			"Fingerprints are SHA-256 key fingerprints in hex, as output by the PEM tool fingerprint command",
+			"The revocation time is RFC 3339, now if not given",
+			sealedKeyUsage,
+			"The scheme is checked against the "+PolicyEnv+" policy: default, fips, cnsa2 or none",
+			"Scheme: "+scheme.Name(),
+		)
+		return
+	}
+	err := checkPolicy(func(p pqc_crypto.Policy) error {
+		return p.CheckSig(scheme)
+	})
+	if err == nil {
+		err = command(t, scheme, a[1:])
+	}
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	t.exit(0)
+}
+
+// parseFingerprint decodes a hex fingerprint ignoring spaces
+func parseFingerprint(s string) ([]byte, error) {
+	fp, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil || len(fp) == 0 {
+		return nil, ErrInvalidFingerprint
+	}
+	return fp, nil
+}
+
+// updateRevocationList reads the list at path, applies update then signs it with the next serial
+func updateRevocationList(t *tool, scheme *pqc_crypto.SigWrapper, keyPath, path string, update func(l *trust.RevocationList) error) error {
+	root, err := t.readSigPrivateKey(scheme, keyPath)
+	if err != nil {
+		return err
+	}
+	bts, err := t.read(path)
+	if err != nil {
+		return err
+	}
+	l, err := trust.ParseRevocationList(bts, root.Public())
+	if err != nil {
+		return err
+	}
+	if err := update(l); err != nil {
+		return err
+	}
+	l.Serial++
+	l.Issued = time.Now()
+	signed, err := l.Sign(root)
+	if err != nil {
+		return err
+	}
+	return t.write(path, signed, 0644)
+}
+
+func revocationCreate(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error {
+	root, err := t.readSigPrivateKey(scheme, a[0])
+	if err != nil {
+		return err
+	}
+	l := &trust.RevocationList{Serial: 1, Issued: time.Now()}
+	signed, err := l.Sign(root)
+	if err != nil {
+		return err
+	}
+	return t.write(a[1], signed, 0644)
+}
+
+func revocationRevoke(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error {
+	fp, err := parseFingerprint(a[2])
+	if err != nil {
+		return err
+	}
+	at := time.Now()
+	if a[3] != "" {
+		at, err = time.Parse(time.RFC3339, a[3])
+		if err != nil {
+			return err
+		}
+	}
+	return updateRevocationList(t, scheme, a[0], a[1], func(l *trust.RevocationList) error {
+		l.Revoke(fp, at)
+		return nil
+	})
+}
+
+func revocationUnrevoke(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error {
+	fp, err := parseFingerprint(a[2])
+	if err != nil {
+		return err
+	}
+	return updateRevocationList(t, scheme, a[0], a[1], func(l *trust.RevocationList) error {
+		if !l.Unrevoke(fp) {
+			return trust.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func revocationInspect(t *tool, scheme *pqc_crypto.SigWrapper, a []string) error {
+	bts, err := t.read(a[0])
+	if err != nil {
+		return err
+	}
+	root, err := scheme.UnmarshalBinaryPublicKey(bts)
+	if err != nil {
+		return err
+	}
+	bts, err = t.read(a[1])
+	if err != nil {
+		return err
+	}
+	l, err := trust.ParseRevocationList(bts, root)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "Serial: %d\nIssued: %s\nRevoked: %d\n", l.Serial, l.Issued.UTC().Format(time.RFC3339), len(l.Revocations))
+	for _, r := range l.Revocations {
+		_, _ = fmt.Fprintf(&b, "%s %s\n", pqc_crypto.FingerprintHex(r.Fingerprint), r.Time.UTC().Format(time.RFC3339))
+	}
+	return t.write(a[2], []byte(b.String()), 0644)
+}
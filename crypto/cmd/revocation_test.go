@@ -0,0 +1,95 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"crypto/sha256"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/1f349/pqc-handshake/crypto/trust"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMainRevocation(t *testing.T) {
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapSig(mldsa44.Scheme())
+	rootPk, root, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	bts, err := root.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/root", bts, 0600))
+	sealed, err := pqc_crypto.SealPrivateKey(root, []byte("correct horse"), pqc_crypto.ScryptParams{LogN: 10, R: 8, P: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/root.sealed", sealed, 0600))
+	bts, err = rootPk.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/root.pub", bts, 0644))
+	pk, _, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	fp, err := pk.(*pqc_crypto.SigPublicKeyWrapper).Fingerprint(sha256.New())
+	assert.NoError(t, err)
+	fpHex := pqc_crypto.FingerprintHex(fp)
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainRevocation(scheme, "a", "b", "c", "d", "e", exitCode(t, code), nil, nil)
+	}
+	parse := func(t *testing.T) *trust.RevocationList {
+		bts, err := os.ReadFile(dir + "/crl")
+		assert.NoError(t, err)
+		l, err := trust.ParseRevocationList(bts, rootPk)
+		assert.NoError(t, err)
+		return l
+	}
+
+	run(t, 0, "create", dir+"/root", dir+"/crl")
+	l := parse(t)
+	assert.Equal(t, uint64(1), l.Serial)
+	assert.Empty(t, l.Revocations)
+
+	run(t, 0, "r", dir+"/root", dir+"/crl", fpHex, "2025-01-02T03:04:05Z")
+	l = parse(t)
+	assert.Equal(t, uint64(2), l.Serial)
+	at, ok := l.RevokedAt(fp)
+	assert.True(t, ok)
+	assert.True(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Equal(at))
+
+	run(t, 0, "i", dir+"/root.pub", dir+"/crl", dir+"/crl.txt")
+	text, err := os.ReadFile(dir + "/crl.txt")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(text), "Serial: 2\n"))
+	assert.Contains(t, string(text), fpHex+" 2025-01-02T03:04:05Z\n")
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	run(t, 0, "unrevoke", dir+"/root.sealed", dir+"/crl", strings.ReplaceAll(fpHex, " ", ""))
+	l = parse(t)
+	assert.Equal(t, uint64(3), l.Serial)
+	assert.Empty(t, l.Revocations)
+
+	t.Run("errors", func(t *testing.T) {
+		run(t, 2, "u", dir+"/root", dir+"/crl", fpHex)
+		run(t, 2, "r", dir+"/root", dir+"/crl", "xyz")
+		run(t, 2, "r", dir+"/root", dir+"/crl", fpHex, "yesterday")
+		run(t, 2, "i", dir+"/root.pub", dir+"/root", dir+"/bad.txt")
+		// a list signed by another root
+		_, other, err := scheme.GenerateKeyPair()
+		assert.NoError(t, err)
+		bts, err := other.MarshalBinary()
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(dir+"/other", bts, 0600))
+		run(t, 2, "r", dir+"/other", dir+"/crl", fpHex)
+	})
+	t.Run("usage", func(t *testing.T) {
+		run(t, 1)
+		run(t, 1, "create", dir+"/root")
+		run(t, 1, "revoke", dir+"/root", dir+"/crl")
+		run(t, 1, "inspect", dir+"/root.pub", dir+"/crl")
+	})
+}
@@ -0,0 +1,140 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	"encoding"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"os"
+)
+
+// sealScheme is the part of KemWrapper / SigWrapper needed to seal and open keys
+type sealScheme struct {
+	name             string
+	check            func(p pqc_crypto.Policy) error
+	unmarshalPrivate func([]byte) (encoding.BinaryMarshaler, error)
+	open             func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error)
+}
+
+// MainSealKem seals raw binary KEM private keys of scheme with a passphrase and opens them again
+func MainSealKem(scheme *pqc_crypto.KemWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainSealKem(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainSealKem is MainSealKem with a custom exit and standard streams
+func TestingMainSealKem(scheme *pqc_crypto.KemWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	sealMain(newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin), sealScheme{
+		name: scheme.Name(),
+		check: func(p pqc_crypto.Policy) error {
+			return p.CheckKem(scheme)
+		},
+		unmarshalPrivate: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPrivateKey(b)
+		},
+		open: func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error) {
+			return pqc_crypto.OpenKemPrivateKey(sealed, passphrase)
+		},
+	})
+}
+
+// MainSealSig seals raw binary signature private keys of scheme with a passphrase and opens them again
+func MainSealSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string) {
+	TestingMainSealSig(scheme, buildName, buildDate, buildVersion, buildAuthor, buildLicense, os.Exit, nil, nil)
+}
+
+// TestingMainSealSig is MainSealSig with a custom exit and standard streams
+func TestingMainSealSig(scheme *pqc_crypto.SigWrapper, buildName, buildDate, buildVersion, buildAuthor, buildLicense string, exit func(code int), stdout, stdin *os.File) {
+	sealMain(newTool(buildName, buildDate, buildVersion, buildAuthor, buildLicense, exit, stdout, stdin), sealScheme{
+		name: scheme.Name(),
+		check: func(p pqc_crypto.Policy) error {
+			return p.CheckSig(scheme)
+		},
+		unmarshalPrivate: func(b []byte) (encoding.BinaryMarshaler, error) {
+			return scheme.UnmarshalBinaryPrivateKey(b)
+		},
+		open: func(sealed, passphrase []byte) (encoding.BinaryMarshaler, error) {
+			return pqc_crypto.OpenSigPrivateKey(sealed, passphrase)
+		},
+	})
+}
+
+// sealScryptParams is changed by tests to keep them fast
+var sealScryptParams = pqc_crypto.DefaultScryptParams
+
+func sealMain(t *tool, scheme sealScheme) {
+	a := args(3)
+	if a[1] == "" || a[2] == "" {
+		a[0] = ""
+	}
+	var command func(t *tool, scheme sealScheme, in, out string) error
+	switch {
+	case isCommand(a[0], "s", "seal"):
+		command = sealSeal
+	case isCommand(a[0], "o", "open"):
+		command = sealOpen
+	default:
+		t.usage(
+			"(s)eal <raw private key> <sealed private key>",
+			"(o)pen <sealed private key> <raw private key>",
+			"",
+			"The passphrase is read from the file descriptor in "+PassphraseFdEnv+" or from "+PassphraseEnv,
+			"The scheme is checked against the "+PolicyEnv+" policy: default, fips, cnsa2 or none",
+			"Scheme: "+scheme.name,
+		)
+		return
+	}
+	err := checkPolicy(scheme.check)
+	if err == nil {
+		err = command(t, scheme, a[1], a[2])
+	}
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	t.exit(0)
+}
+
+func sealSeal(t *tool, scheme sealScheme, in, out string) error {
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+	bts, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	key, err := scheme.unmarshalPrivate(bts)
+	clear(bts)
+	if err != nil {
+		return err
+	}
+	sealed, err := pqc_crypto.SealPrivateKey(key, pass, sealScryptParams)
+	if err != nil {
+		return err
+	}
+	return t.write(out, sealed, 0600)
+}
+
+func sealOpen(t *tool, scheme sealScheme, in, out string) error {
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+	sealed, err := t.read(in)
+	if err != nil {
+		return err
+	}
+	key, err := scheme.open(sealed, pass)
+	if err != nil {
+		return err
+	}
+	if name, err := pqc_crypto.KeySchemeName(key); err != nil || name != scheme.name {
+		return ErrSchemeMismatch
+	}
+	raw, err := key.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	defer clear(raw)
+	return t.write(out, raw, 0600)
+}
@@ -0,0 +1,129 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package cmd
+
+import (
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func fastSealScrypt(t *testing.T) {
+	o := sealScryptParams
+	sealScryptParams = pqc_crypto.ScryptParams{LogN: 10, R: 8, P: 1}
+	t.Cleanup(func() {
+		sealScryptParams = o
+	})
+}
+
+func TestMainSealKem(t *testing.T) {
+	fastSealScrypt(t)
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapKem(mlkem768.Scheme())
+	_, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	kBts, err := k.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/privkey", kBts, 0600))
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, stdout, stdin *os.File, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainSealKem(scheme, "a", "b", "c", "d", "e", exitCode(t, code), stdout, stdin)
+	}
+
+	t.Run("no passphrase", func(t *testing.T) {
+		run(t, 2, nil, nil, "seal", dir+"/privkey", dir+"/privkey.sealed")
+	})
+	t.Run("env seal open", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "correct horse")
+		run(t, 0, nil, nil, "seal", dir+"/privkey", dir+"/privkey.sealed")
+		stat, err := os.Stat(dir + "/privkey.sealed")
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+		bts, err := os.ReadFile(dir + "/privkey.sealed")
+		assert.NoError(t, err)
+		rk, err := pqc_crypto.OpenKemPrivateKey(bts, []byte("correct horse"))
+		assert.NoError(t, err)
+		assert.True(t, k.Equals(rk))
+		run(t, 0, getStdOut(dir), nil, "O", dir+"/privkey.sealed", "-")
+		assert.True(t, checkStdOut(dir, kBts))
+	})
+	t.Run("fd open", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		assert.NoError(t, err)
+		_, err = w.WriteString("correct horse\n")
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		t.Setenv(PassphraseEnv, "wrong")
+		t.Setenv(PassphraseFdEnv, strconv.Itoa(int(r.Fd())))
+		run(t, 0, nil, nil, "open", dir+"/privkey.sealed", dir+"/privkey.raw")
+		bts, err := os.ReadFile(dir + "/privkey.raw")
+		assert.NoError(t, err)
+		assert.Equal(t, kBts, bts)
+	})
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "wrong")
+		run(t, 2, nil, nil, "o", dir+"/privkey.sealed", dir+"/wrong.raw")
+		_, err := os.Stat(dir + "/wrong.raw")
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("policy", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "correct horse")
+		t.Setenv(PolicyEnv, "cnsa2")
+		run(t, 2, nil, nil, "seal", dir+"/privkey", dir+"/cnsa2.sealed")
+	})
+	t.Run("invalid fd", func(t *testing.T) {
+		t.Setenv(PassphraseFdEnv, "abc")
+		run(t, 2, nil, nil, "o", dir+"/privkey.sealed", dir+"/invalid.raw")
+	})
+	t.Run("usage", func(t *testing.T) {
+		run(t, 1, nil, nil)
+		run(t, 1, nil, nil, "seal", dir+"/privkey")
+		run(t, 1, nil, nil, "abc", dir+"/privkey", dir+"/privkey.sealed")
+	})
+}
+
+func TestMainSealSig(t *testing.T) {
+	fastSealScrypt(t)
+	t.Setenv(PassphraseEnv, "correct horse")
+	dir := t.TempDir()
+	scheme := pqc_crypto.WrapSig(mldsa44.Scheme())
+	_, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	kBts, err := k.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/privkey", kBts, 0600))
+	var oargs = os.Args
+	defer func() {
+		os.Args = oargs
+	}()
+	run := func(t *testing.T, code int, stdout, stdin *os.File, a ...string) {
+		os.Args = append([]string{"testing"}, a...)
+		TestingMainSealSig(scheme, "a", "b", "c", "d", "e", exitCode(t, code), stdout, stdin)
+	}
+	run(t, 0, nil, nil, "s", dir+"/privkey", dir+"/privkey.sealed")
+	run(t, 0, nil, nil, "o", dir+"/privkey.sealed", dir+"/privkey.raw")
+	bts, err := os.ReadFile(dir + "/privkey.raw")
+	assert.NoError(t, err)
+	assert.Equal(t, kBts, bts)
+	bts, err = os.ReadFile(dir + "/privkey.sealed")
+	assert.NoError(t, err)
+	rk, err := pqc_crypto.OpenSigPrivateKey(bts, []byte("correct horse"))
+	assert.NoError(t, err)
+	assert.True(t, k.Equals(rk))
+
+	// a sealed key of another scheme is rejected
+	_, k768, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	bts, err = pqc_crypto.SealPrivateKey(k768, []byte("correct horse"), sealScryptParams)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dir+"/k768.sealed", bts, 0600))
+	run(t, 2, nil, nil, "o", dir+"/k768.sealed", dir+"/k768.raw")
+}
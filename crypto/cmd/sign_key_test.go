@@ -50,25 +50,25 @@ func TestMainSignKey(t *testing.T) {
 	}
 	t.Run("command privkey epubkey sig", func(t *testing.T) {
 		os.Args = []string{"testing", dir + "/privkey", dir + "/epubkey", dir + "/sig"}
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, nil, nil, 0, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, nil, nil, time.Time{}, false)
 	})
 	t.Run("command privkey epubkey -", func(t *testing.T) {
 		os.Args = []string{"testing", dir + "/privkey", dir + "/epubkey", "-"}
 		stdout := getStdOut(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, stdout, nil, 0, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, stdout, nil, time.Time{}, false)
 	})
 	t.Run("command - epubkey sig Now()+1h", func(t *testing.T) {
 		exp := time.Now().Add(time.Hour)
 		os.Args = []string{"testing", "-", dir + "/epubkey", dir + "/sig", exp.Format(cmd.RFC3339Milli)}
 		assert.NoError(t, writeStdIn(dir, kBts))
 		stdin := getStdIn(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, exp, tFunc, nil, stdin, 0, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, exp, tFunc, nil, stdin, time.Time{}, false)
 	})
 	t.Run("command privkey - sig 1h", func(t *testing.T) {
 		os.Args = []string{"testing", dir + "/privkey", "-", dir + "/sig", "1h"}
 		assert.NoError(t, writeStdIn(dir, ekpBts))
 		stdin := getStdIn(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, nil, stdin, 0, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, time.Time{}, tFunc, nil, stdin, time.Time{}, false)
 	})
 	t.Run("command - epubkey - UnixMilli(Now()+1h)", func(t *testing.T) {
 		exp := time.Now().Add(time.Hour)
@@ -76,7 +76,7 @@ func TestMainSignKey(t *testing.T) {
 		assert.NoError(t, writeStdIn(dir, kBts))
 		stdin := getStdIn(dir)
 		stdout := getStdOut(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, exp, tFunc, stdout, stdin, 0, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, time.Time{}, exp, tFunc, stdout, stdin, time.Time{}, false)
 	})
 	t.Run("command privkey - - 1h Now()+10ms", func(t *testing.T) {
 		iss := time.Now().Add(10 * time.Millisecond)
@@ -85,7 +85,7 @@ func TestMainSignKey(t *testing.T) {
 		assert.NoError(t, writeStdIn(dir, ekpBts))
 		stdin := getStdIn(dir)
 		stdout := getStdOut(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, 50*time.Millisecond, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, iss, false)
 	})
 	t.Run("fail-verify privkey - - 1h Now()+1s", func(t *testing.T) {
 		iss := time.Now().Add(time.Second)
@@ -94,7 +94,7 @@ func TestMainSignKey(t *testing.T) {
 		assert.NoError(t, writeStdIn(dir, ekpBts))
 		stdin := getStdIn(dir)
 		stdout := getStdOut(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, 0, true)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, iss.Add(-time.Millisecond), true)
 	})
 	t.Run("command privkey - - Now()+1m UnixMilli(Now()+25ms)", func(t *testing.T) {
 		iss := time.Now().Add(25 * time.Millisecond)
@@ -103,7 +103,7 @@ func TestMainSignKey(t *testing.T) {
 		assert.NoError(t, writeStdIn(dir, ekpBts))
 		stdin := getStdIn(dir)
 		stdout := getStdOut(dir)
-		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, 100*time.Millisecond, false)
+		testMainSignKey(t, dir, sigScheme, tHash, ekpBts, kp, iss, exp, tFunc, stdout, stdin, iss, false)
 	})
 	t.Run("Usage", func(t *testing.T) {
 		os.Args = []string{"testing"}
@@ -116,11 +116,8 @@ func TestMainSignKey(t *testing.T) {
 }
 
 func testMainSignKey(t *testing.T, dir string, sigScheme crypto.SigScheme, hashScheme hash.Hash,
-	ekp []byte, kp crypto.SigPublicKey, issue, expiry time.Time, close func(code int), stdout, stdin *os.File, delay time.Duration, failing bool) {
+	ekp []byte, kp crypto.SigPublicKey, issue, expiry time.Time, close func(code int), stdout, stdin *os.File, at time.Time, failing bool) {
 	cmd.TestingMainSignKey(sigScheme, hashScheme, "a", "b", "c", "d", "e", close, stdout, stdin)
-	if delay > 0 {
-		time.Sleep(delay)
-	}
 	var err error
 	var bts []byte
 	if stdout == nil {
@@ -137,6 +134,16 @@ func testMainSignKey(t *testing.T, dir string, sigScheme crypto.SigScheme, hashS
 	if !expiry.IsZero() {
 		assert.Equal(t, time.UnixMilli(expiry.UnixMilli()), sd.ExpiryTime)
 	}
-	v := sd.Verify(hashScheme, kp)
-	assert.Equal(t, !failing, v)
+	if at.IsZero() || failing {
+		assert.Equal(t, !failing, sd.Verify(hashScheme, kp))
+	}
+	assert.NoError(t, pqc_crypto.SigDataVerifier{}.VerifySignature(sd, hashScheme, kp))
+	if !at.IsZero() {
+		err = pqc_crypto.SigDataVerifier{Clock: pqc_crypto.FixedClock(at)}.Verify(sd, hashScheme, kp)
+		if failing {
+			assert.ErrorIs(t, err, pqc_crypto.ErrSigNotYetValid)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
 }
@@ -0,0 +1,130 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"errors"
+)
+
+// COSE_Key encoding of ML-DSA keys using the "AKP" key type from the IETF COSE ML-DSA draft, ML-KEM has no COSE
+// algorithm registered yet so is not supported
+
+const (
+	COSEKeyTypeAKP = 7
+
+	coseLabelKty  = 1
+	coseLabelKid  = 2
+	coseLabelAlg  = 3
+	coseLabelPub  = -1
+	coseLabelPriv = -2
+)
+
+var coseAlgs = map[string]int64{
+	"ML-DSA-44": -48,
+	"ML-DSA-65": -49,
+	"ML-DSA-87": -50,
+}
+
+var ErrInvalidCOSEKey = errors.New("invalid COSE_Key")
+
+func coseMap(a *akpKey) (map[int64]cborMapValue, error) {
+	alg, ok := coseAlgs[a.alg]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	return map[int64]cborMapValue{
+		coseLabelKty: int64(COSEKeyTypeAKP),
+		coseLabelAlg: alg,
+		coseLabelPub: a.pub,
+	}, nil
+}
+
+// COSEKeyThumbprint returns the RFC 9679 SHA-256 thumbprint of an ML-DSA public key, or of the public part of a
+// private key, over the required parameters kty, alg and pub
+func COSEKeyThumbprint(key encoding.BinaryMarshaler) ([]byte, error) {
+	pub, _ := publicOf(key)
+	a, err := newAKPKey(pub, nil)
+	if err != nil {
+		return nil, err
+	}
+	return coseThumbprint(a)
+}
+
+func coseThumbprint(a *akpKey) ([]byte, error) {
+	m, err := coseMap(a)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := cborMarshalMap(m)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(bts)
+	return sum[:], nil
+}
+
+// MarshalCOSEKey encodes an ML-DSA public key, or a private key with the seed it was derived from, as a COSE_Key
+// with the kid set to its thumbprint
+func MarshalCOSEKey(key encoding.BinaryMarshaler, seed []byte) ([]byte, error) {
+	a, err := newAKPKey(key, seed)
+	if err != nil {
+		return nil, err
+	}
+	m, err := coseMap(a)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := coseThumbprint(a)
+	if err != nil {
+		return nil, err
+	}
+	m[coseLabelKid] = kid
+	if a.priv != nil {
+		m[coseLabelPriv] = a.priv
+	}
+	return cborMarshalMap(m)
+}
+
+// ParseCOSEKey decodes a COSE_Key into a crypto.SigPublicKey or crypto.SigPrivateKey and returns its kid
+func ParseCOSEKey(data []byte) (key any, kid []byte, err error) {
+	m, err := cborUnmarshalMap(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kty, ok := m[coseLabelKty].(int64); !ok || kty != COSEKeyTypeAKP {
+		return nil, nil, ErrInvalidCOSEKey
+	}
+	alg, ok := m[coseLabelAlg].(int64)
+	if !ok {
+		return nil, nil, ErrInvalidCOSEKey
+	}
+	a := &akpKey{}
+	for name, v := range coseAlgs {
+		if v == alg {
+			a.alg = name
+		}
+	}
+	if a.alg == "" {
+		return nil, nil, ErrUnknownAlgorithm
+	}
+	if a.pub, ok = m[coseLabelPub].([]byte); !ok {
+		return nil, nil, ErrInvalidCOSEKey
+	}
+	if v, found := m[coseLabelPriv]; found {
+		if a.priv, ok = v.([]byte); !ok {
+			return nil, nil, ErrInvalidCOSEKey
+		}
+	}
+	if v, found := m[coseLabelKid]; found {
+		if kid, ok = v.([]byte); !ok {
+			return nil, nil, ErrInvalidCOSEKey
+		}
+	}
+	key, err = a.key()
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, kid, nil
+}
@@ -0,0 +1,90 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCOSEKey(t *testing.T) {
+	for _, name := range []string{"ML-DSA-44", "ML-DSA-65", "ML-DSA-87"} {
+		scheme := SigSchemeByName(name)
+		t.Run(name, func(t *testing.T) {
+			seed := testSeed(scheme.SeedSize())
+			pk, k, err := scheme.DeriveKeyPair(seed)
+			assert.NoError(t, err)
+			thumb, err := COSEKeyThumbprint(pk)
+			assert.NoError(t, err)
+			kThumb, err := COSEKeyThumbprint(k)
+			assert.NoError(t, err)
+			assert.Equal(t, thumb, kThumb)
+
+			pc, err := MarshalCOSEKey(pk, nil)
+			assert.NoError(t, err)
+			rpk, kid, err := ParseCOSEKey(pc)
+			assert.NoError(t, err)
+			assert.Equal(t, thumb, kid)
+			assert.True(t, pk.Equals(rpk.(crypto.SigPublicKey)))
+			kc, err := MarshalCOSEKey(k, seed)
+			assert.NoError(t, err)
+			rk, kid, err := ParseCOSEKey(kc)
+			assert.NoError(t, err)
+			assert.Equal(t, thumb, kid)
+			assert.True(t, k.Equals(rk.(crypto.SigPrivateKey)))
+		})
+	}
+}
+
+func TestCOSEKeyThumbprint(t *testing.T) {
+	pk, _, err := WrapSig(mldsa44.Scheme()).DeriveKeyPair(testSeed(32))
+	assert.NoError(t, err)
+	bts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+	// {1: 7, 3: -48, -1: h'...'} in deterministic encoding, the 1312 byte key has a 2 byte length
+	expected := append([]byte{0xa3, 0x01, 0x07, 0x03, 0x38, 0x2f, 0x20, 0x59, 0x05, 0x20}, bts...)
+	sum := sha256.Sum256(expected)
+	thumb, err := COSEKeyThumbprint(pk)
+	assert.NoError(t, err)
+	assert.Equal(t, sum[:], thumb)
+}
+
+func TestCOSEKeyInvalid(t *testing.T) {
+	kpk, _, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = MarshalCOSEKey(kpk, nil)
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	for name, data := range map[string][]byte{
+		"Empty":         {},
+		"Not a map":     {0x01},
+		"Truncated":     {0xa1, 0x01},
+		"Duplicate key": {0xa2, 0x01, 0x07, 0x01, 0x07},
+		"Trailing data": {0xa1, 0x01, 0x07, 0x00},
+		"Text value":    {0xa1, 0x01, 0x61, 0x41},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ParseCOSEKey(data)
+			assert.ErrorIs(t, err, ErrInvalidCBOR)
+		})
+	}
+	_, _, err = ParseCOSEKey([]byte{0xa1, 0x01, 0x02})
+	assert.ErrorIs(t, err, ErrInvalidCOSEKey)
+	_, _, err = ParseCOSEKey([]byte{0xa3, 0x01, 0x07, 0x03, 0x26, 0x20, 0x40})
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	m := map[int64]cborMapValue{
+		0: int64(23), 1: int64(24), 2: int64(255), 3: int64(256), 4: int64(65536), 5: int64(1 << 40),
+		-1: int64(-1), -25: int64(-1 << 40), 100: []byte{}, -100: make([]byte, 300),
+	}
+	bts, err := cborMarshalMap(m)
+	assert.NoError(t, err)
+	rm, err := cborUnmarshalMap(bts)
+	assert.NoError(t, err)
+	assert.Equal(t, m, rm)
+}
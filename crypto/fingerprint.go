@@ -0,0 +1,149 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"strings"
+)
+
+// Fingerprints hash the tagged key (see MarshalTagged) so the same key bytes under different schemes never share a
+// fingerprint
+
+// keyIDSize is the number of bytes of the SHA-256 fingerprint used for KeyID
+const keyIDSize = 16
+
+// fingerprintWords has a distinct word for every byte value, for reading fingerprints aloud
+var fingerprintWords = [256]string{
+	"acid", "acorn", "actor", "adobe", "agent", "alarm", "album", "alley",
+	"amber", "angel", "anvil", "apple", "apron", "arena", "armor", "arrow",
+	"atlas", "attic", "audio", "autumn", "axis", "bacon", "badge", "bagel",
+	"baker", "balsa", "bamboo", "banjo", "barn", "basil", "basin", "beach",
+	"beard", "bench", "berry", "bison", "blade", "blaze", "bloom", "board",
+	"boat", "bonus", "boot", "bottle", "bounce", "bread", "brick", "bridge",
+	"brush", "bucket", "bugle", "cabin", "cactus", "camel", "candle", "canoe",
+	"canvas", "carbon", "cargo", "carpet", "castle", "cedar", "cello", "chalk",
+	"cherry", "chess", "chief", "cider", "cinema", "circus", "clock", "cloud",
+	"clover", "cobra", "cocoa", "comet", "copper", "coral", "cotton", "cougar",
+	"crane", "crater", "crayon", "crystal", "cube", "cupid", "daisy", "delta",
+	"denim", "desert", "diesel", "dingo", "dinner", "disco", "dolphin", "donkey",
+	"dragon", "drum", "eagle", "easel", "echo", "eclipse", "elbow", "ember",
+	"engine", "epoch", "falcon", "fiddle", "flute", "forest", "fossil", "fox",
+	"frost", "galaxy", "garden", "garlic", "gecko", "geyser", "ginger", "glacier",
+	"globe", "goblet", "gopher", "granite", "gravel", "guitar", "hammer", "harbor",
+	"harp", "hazel", "helmet", "heron", "honey", "hornet", "hotel", "husky",
+	"igloo", "indigo", "iris", "island", "ivory", "jacket", "jaguar", "jasmine",
+	"jelly", "jewel", "jigsaw", "jungle", "kayak", "kettle", "kiwi", "koala",
+	"ladder", "lagoon", "lantern", "laser", "lemon", "lily", "lizard", "llama",
+	"lobster", "locket", "lotus", "magnet", "mango", "maple", "marble", "meadow",
+	"melon", "meteor", "mint", "mirror", "monkey", "mosaic", "motor", "nectar",
+	"needle", "nickel", "noodle", "oasis", "ocean", "olive", "onion", "opal",
+	"orbit", "orchid", "otter", "oyster", "paddle", "panda", "papaya", "parrot",
+	"pebble", "pepper", "piano", "pickle", "pilot", "pine", "pirate", "plasma",
+	"plum", "pocket", "polar", "pony", "poppy", "potato", "prism", "pumpkin",
+	"puzzle", "quartz", "quill", "rabbit", "radar", "radio", "raven", "ribbon",
+	"rocket", "ruby", "saddle", "salmon", "satin", "saturn", "scarf", "shadow",
+	"shell", "sierra", "silver", "sketch", "slate", "sonar", "spider", "spruce",
+	"squid", "statue", "sugar", "summit", "sunset", "swan", "tango", "tiger",
+	"timber", "toast", "tomato", "topaz", "torch", "tractor", "tulip", "tundra",
+	"turtle", "umbrella", "unicorn", "valley", "velvet", "violin", "walnut", "walrus",
+}
+
+// fingerprint hashes the tagged key with h, which is reset first
+func fingerprint(h hash.Hash, key encoding.BinaryMarshaler) ([]byte, error) {
+	tagged, err := MarshalTagged(key)
+	if err != nil {
+		return nil, err
+	}
+	h.Reset()
+	h.Write(tagged)
+	return h.Sum(nil), nil
+}
+
+// keyID is the hex encoded start of the SHA-256 fingerprint
+func keyID(key encoding.BinaryMarshaler) string {
+	fp, err := fingerprint(sha256.New(), key)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(fp[:keyIDSize])
+}
+
+// Fingerprint hashes the scheme name and public key with h
+func (k KemPublicKeyWrapper) Fingerprint(h hash.Hash) ([]byte, error) {
+	if k.PublicKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return fingerprint(h, k)
+}
+
+// KeyID is the hex encoded first 16 bytes of the SHA-256 fingerprint, or empty for a nil key
+func (k KemPublicKeyWrapper) KeyID() string {
+	if k.PublicKey == nil {
+		return ""
+	}
+	return keyID(k)
+}
+
+// Fingerprint hashes the scheme name and public key with h
+func (k SigPublicKeyWrapper) Fingerprint(h hash.Hash) ([]byte, error) {
+	if k.PublicKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return fingerprint(h, k)
+}
+
+// KeyID is the hex encoded first 16 bytes of the SHA-256 fingerprint, or empty for a nil key
+func (k SigPublicKeyWrapper) KeyID() string {
+	if k.PublicKey == nil {
+		return ""
+	}
+	return keyID(k)
+}
+
+// FingerprintHex renders a fingerprint as upper case hex in groups of 4 digits, e.g. "1A2B 3C4D"
+func FingerprintHex(fp []byte) string {
+	enc := strings.ToUpper(hex.EncodeToString(fp))
+	var sb strings.Builder
+	sb.Grow(len(enc) + len(enc)/4)
+	for i := 0; i < len(enc); i += 4 {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(enc[i:min(i+4, len(enc))])
+	}
+	return sb.String()
+}
+
+// FingerprintWords renders each byte of a fingerprint as a word, separated by spaces
+func FingerprintWords(fp []byte) string {
+	words := make([]string, len(fp))
+	for i, b := range fp {
+		words[i] = fingerprintWords[b]
+	}
+	return strings.Join(words, " ")
+}
+
+// FingerprintText describes a public key, or the public part of a private key, for comparing keys out of band and in
+// logs, giving the scheme, key ID, SHA-256 fingerprint and its word rendering one per line
+func FingerprintText(key encoding.BinaryMarshaler) (string, error) {
+	if key == nil {
+		return "", crypto.ErrKeyNil
+	}
+	pub, _ := publicOf(key)
+	name, err := KeySchemeName(pub)
+	if err != nil {
+		return "", err
+	}
+	fp, err := fingerprint(sha256.New(), pub)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Scheme: %s\nKey ID: %s\nSHA-256: %s\nWords: %s\n", name, hex.EncodeToString(fp[:keyIDSize]),
+		FingerprintHex(fp), FingerprintWords(fp)), nil
+}
@@ -0,0 +1,89 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/dilithium/mode2"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	spk := pk.(*SigPublicKeyWrapper)
+	fp, err := spk.Fingerprint(sha256.New())
+	assert.NoError(t, err)
+	tagged, err := MarshalTagged(pk)
+	assert.NoError(t, err)
+	sum := sha256.Sum256(tagged)
+	assert.Equal(t, sum[:], fp)
+
+	// the hash is reset first
+	h := sha512.New()
+	h.Write([]byte("junk"))
+	fp512, err := spk.Fingerprint(h)
+	assert.NoError(t, err)
+	sum512 := sha512.Sum512(tagged)
+	assert.Equal(t, sum512[:], fp512)
+
+	assert.Len(t, spk.KeyID(), 32)
+	assert.True(t, strings.HasPrefix(FingerprintHex(fp), strings.ToUpper(spk.KeyID()[:4])+" "))
+	rpk := k.Public().(*SigPublicKeyWrapper)
+	assert.Equal(t, spk.KeyID(), rpk.KeyID())
+
+	// the same key bytes under another scheme name have a different fingerprint
+	bts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+	d2, err := WrapSig(mode2.Scheme()).UnmarshalBinaryPublicKey(bts)
+	if err == nil {
+		assert.NotEqual(t, spk.KeyID(), d2.(*SigPublicKeyWrapper).KeyID())
+	}
+
+	kpk, _, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	kfp, err := kpk.(*KemPublicKeyWrapper).Fingerprint(sha256.New())
+	assert.NoError(t, err)
+	assert.Len(t, kfp, sha256.Size)
+	assert.NotEqual(t, spk.KeyID(), kpk.(*KemPublicKeyWrapper).KeyID())
+
+	_, err = KemPublicKeyWrapper{}.Fingerprint(sha256.New())
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	assert.Empty(t, SigPublicKeyWrapper{}.KeyID())
+}
+
+func TestFingerprintRendering(t *testing.T) {
+	assert.Equal(t, "", FingerprintHex(nil))
+	assert.Equal(t, "0123 4567 89AB CDEF", FingerprintHex([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}))
+	assert.Equal(t, "0123 45", FingerprintHex([]byte{0x01, 0x23, 0x45}))
+	assert.Equal(t, "acid acorn walrus", FingerprintWords([]byte{0, 1, 255}))
+
+	seen := make(map[string]bool)
+	for _, w := range fingerprintWords {
+		assert.NotEmpty(t, w)
+		assert.False(t, seen[w], w)
+		seen[w] = true
+	}
+}
+
+func TestFingerprintText(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	text, err := FingerprintText(pk)
+	assert.NoError(t, err)
+	fp, err := pk.(*SigPublicKeyWrapper).Fingerprint(sha256.New())
+	assert.NoError(t, err)
+	assert.Equal(t, "Scheme: ML-DSA-44\nKey ID: "+pk.(*SigPublicKeyWrapper).KeyID()+"\nSHA-256: "+FingerprintHex(fp)+
+		"\nWords: "+FingerprintWords(fp)+"\n", text)
+	ktext, err := FingerprintText(k)
+	assert.NoError(t, err)
+	assert.Equal(t, text, ktext)
+	_, err = FingerprintText(nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
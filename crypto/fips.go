@@ -0,0 +1,198 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	stdcrypto "crypto"
+	"crypto/fips140"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/sign"
+	"hash"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FIPS mode limits the process to FIPS 203 / 204 / 205 approved post quantum schemes. It starts enabled if
+// PQC_FIPS_MODE is true or the Go FIPS 140-3 module is enabled (GODEBUG=fips140=on)
+
+const FIPSModeEnv = "PQC_FIPS_MODE"
+
+var ErrSelfTest = errors.New("self-test failed")
+var ErrHashNotApproved = errors.New("hash not approved in FIPS mode")
+
+var fipsMode atomic.Bool
+
+func init() {
+	on, _ := strconv.ParseBool(os.Getenv(FIPSModeEnv))
+	fipsMode.Store(on || fips140.Enabled())
+}
+
+// FIPSMode reports if FIPS mode is active
+func FIPSMode() bool {
+	return fipsMode.Load()
+}
+
+// SetFIPSMode enables or disables FIPS mode, this should be done before wrapping any schemes as wrappers already
+// returned keep working
+func SetFIPSMode(on bool) {
+	fipsMode.Store(on)
+}
+
+// Mode describes the active mode for logs
+func Mode() string {
+	if FIPSMode() {
+		return "FIPS (approved post quantum schemes only)"
+	}
+	return "standard"
+}
+
+// fipsApproved is true for FIPS approved pure post quantum schemes
+func fipsApproved(name string) bool {
+	info, ok := SchemeInfoByName(name)
+	return ok && info.FIPSApproved && info.Classification == PostQuantum
+}
+
+// approvedHashes are the FIPS 180-4 and 202 hashes, SHA-1 is excluded as it is not approved for signatures. Hashes are
+// identified with HashOf as the standard library uses one type for every size of SHA-256, SHA-512 and SHA-3
+var approvedHashes = map[stdcrypto.Hash]bool{
+	stdcrypto.SHA224:     true,
+	stdcrypto.SHA256:     true,
+	stdcrypto.SHA384:     true,
+	stdcrypto.SHA512:     true,
+	stdcrypto.SHA512_224: true,
+	stdcrypto.SHA512_256: true,
+	stdcrypto.SHA3_224:   true,
+	stdcrypto.SHA3_256:   true,
+	stdcrypto.SHA3_384:   true,
+	stdcrypto.SHA3_512:   true,
+}
+
+// CheckSigHash returns ErrHashNotApproved in FIPS mode if h is not an approved hash, a nil hash signs the full data so
+// is always accepted
+func CheckSigHash(h hash.Hash) error {
+	if h == nil || !FIPSMode() || approvedHashes[HashOf(h)] {
+		return nil
+	}
+	return ErrHashNotApproved
+}
+
+// NewSigData is crypto.NewSigData checking the hash with CheckSigHash first
+func NewSigData(data []byte, issue, expiry time.Time, h hash.Hash, key crypto.SigPrivateKey) (*crypto.SigData, error) {
+	if err := CheckSigHash(h); err != nil {
+		return nil, err
+	}
+	return crypto.NewSigData(data, issue, expiry, h, key), nil
+}
+
+// VerifySigData is SigData.Verify checking the hash with CheckSigHash first
+func VerifySigData(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) (bool, error) {
+	if err := CheckSigHash(h); err != nil {
+		return false, err
+	}
+	return sigData.Verify(h, key), nil
+}
+
+// selfTest runs once per wrapper, the result is kept
+type selfTest struct {
+	once sync.Once
+	err  error
+}
+
+func (t *selfTest) run(f func() error) error {
+	t.once.Do(func() {
+		t.err = f()
+	})
+	return t.err
+}
+
+// selfTestKATs are the hex SHA-256 of the known answer test outputs, see kemSelfTest and sigSelfTest
+var selfTestKATs = map[string]string{
+	"ML-KEM-512":  "6b163b49bb0622393bc8e0b4b17c128dd677dbdba08715c61506791491dc3d4c",
+	"ML-KEM-768":  "7132bcbae2b48c3aa97b947cd7d05d8f5976b070951228adaf9bfe94bd27bade",
+	"ML-KEM-1024": "90f81af58e4e3932a7375751bad6df71261727f138e6327ddbf9fea427bc6178",
+	"ML-DSA-44":   "9041b171fab5c3266b4633acb6a7b64444baa1bcdacd3b3abd29986fdb6da100",
+	"ML-DSA-65":   "53436773d52237c2b3a7ca1bff6a3ebc51e33a5096dfa8bfc4f556a620a5dcd8",
+	"ML-DSA-87":   "7c3bef885b6ded2d6888085b790439152129d5cd2c5d007128475653132c177d",
+}
+
+// selfTestBytes returns n deterministic bytes
+func selfTestBytes(n int, start byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = start + byte(i)
+	}
+	return b
+}
+
+func checkKAT(name string, parts ...[]byte) error {
+	want, ok := selfTestKATs[name]
+	if !ok {
+		return nil
+	}
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != want {
+		return fmt.Errorf("%w: %s known answer", ErrSelfTest, name)
+	}
+	return nil
+}
+
+// kemSelfTest derives a key pair from a fixed seed, encapsulates deterministically and decapsulates, checking the
+// known answer where there is one
+func kemSelfTest(scheme kem.Scheme) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrSelfTest, scheme.Name(), r)
+		}
+	}()
+	pk, k := scheme.DeriveKeyPair(selfTestBytes(scheme.SeedSize(), 0))
+	ct, ss, err := scheme.EncapsulateDeterministically(pk, selfTestBytes(scheme.EncapsulationSeedSize(), 128))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrSelfTest, scheme.Name(), err)
+	}
+	ss2, err := scheme.Decapsulate(k, ct)
+	if err != nil || !bytes.Equal(ss, ss2) {
+		return fmt.Errorf("%w: %s decapsulation", ErrSelfTest, scheme.Name())
+	}
+	pkBts, err := pk.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrSelfTest, scheme.Name(), err)
+	}
+	return checkKAT(scheme.Name(), pkBts, ct, ss)
+}
+
+// sigSelfTest derives a key pair from a fixed seed, signs and verifies a fixed message and checks a modified message
+// fails, checking the known answer where there is one
+func sigSelfTest(scheme sign.Scheme) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrSelfTest, scheme.Name(), r)
+		}
+	}()
+	pk, k := scheme.DeriveKey(selfTestBytes(scheme.SeedSize(), 0))
+	msg := selfTestBytes(64, 64)
+	sig := scheme.Sign(k, msg, nil)
+	if !scheme.Verify(pk, msg, sig, nil) {
+		return fmt.Errorf("%w: %s verify", ErrSelfTest, scheme.Name())
+	}
+	msg[0] ^= 1
+	if scheme.Verify(pk, msg, sig, nil) {
+		return fmt.Errorf("%w: %s verified modified message", ErrSelfTest, scheme.Name())
+	}
+	pkBts, err := pk.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrSelfTest, scheme.Name(), err)
+	}
+	return checkKAT(scheme.Name(), pkBts, sig)
+}
@@ -0,0 +1,149 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"errors"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/dilithium/mode2"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func setFIPSMode(t *testing.T, on bool) {
+	o := FIPSMode()
+	SetFIPSMode(on)
+	t.Cleanup(func() {
+		SetFIPSMode(o)
+	})
+}
+
+func TestFIPSMode(t *testing.T) {
+	setFIPSMode(t, false)
+	// keys made before FIPS mode still report their scheme
+	kyber := WrapKem(kyber768.Scheme())
+	kyberPk, kyberSk, err := kyber.GenerateKeyPair()
+	assert.NoError(t, err)
+	kyberBts, err := kyberPk.MarshalBinary()
+	assert.NoError(t, err)
+	dilithium := WrapSig(mode2.Scheme())
+	dilithiumPk, dilithiumSk, err := dilithium.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	SetFIPSMode(true)
+	assert.True(t, FIPSMode())
+	assert.Contains(t, Mode(), "FIPS")
+
+	_, err = TryWrapKem(kyber768.Scheme())
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = TryWrapSig(mode2.Scheme())
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	// WrapSig does not panic but the wrapper refuses to work
+	assert.NotPanics(t, func() {
+		assert.Same(t, dilithium, WrapSig(mode2.Scheme()))
+	})
+	_, _, err = kyber.GenerateKeyPair()
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, _, err = kyber.Encapsulate(kyberPk)
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, _, err = kyber.AppendEncapsulate(nil, nil, kyberPk)
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = kyber.Decapsulate(kyberSk, make([]byte, kyber.CiphertextSize()))
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = kyber.UnmarshalBinaryPublicKey(kyberBts)
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, _, err = dilithium.GenerateKeyPair()
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = dilithium.Sign(dilithiumSk, []byte("msg"))
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = dilithium.AppendSign(nil, dilithiumSk, []byte("msg"))
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = dilithium.Verify(dilithiumPk, []byte("msg"), make([]byte, dilithium.SignatureSize()))
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	assert.Nil(t, KemSchemeByName("Kyber768"))
+	assert.Nil(t, SigSchemeByName("Dilithium2"))
+	assert.NotNil(t, kyberPk.Scheme())
+
+	kemScheme, err := TryWrapKem(mlkem768.Scheme())
+	assert.NoError(t, err)
+	assert.Same(t, kemScheme, KemSchemeByName("ML-KEM-768"))
+	sigScheme, err := TryWrapSig(mldsa44.Scheme())
+	assert.NoError(t, err)
+	assert.Same(t, sigScheme, SigSchemeByName("ml-dsa-44"))
+	pk, k, err := sigScheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	sig, err := sigScheme.Sign(k, []byte("msg"))
+	assert.NoError(t, err)
+	ok, err := sigScheme.Verify(pk, []byte("msg"), sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	SetFIPSMode(false)
+	assert.Equal(t, "standard", Mode())
+	assert.NotNil(t, KemSchemeByName("Kyber768"))
+	_, _, err = kyber.Encapsulate(kyberPk)
+	assert.NoError(t, err)
+}
+
+func TestSelfTest(t *testing.T) {
+	for _, s := range knownKemSchemes {
+		assert.NoError(t, kemSelfTest(s), s.Name())
+	}
+	for _, s := range knownSigSchemes {
+		assert.NoError(t, sigSelfTest(s), s.Name())
+	}
+
+	o := selfTestKATs["ML-KEM-768"]
+	selfTestKATs["ML-KEM-768"] = "00"
+	assert.ErrorIs(t, kemSelfTest(mlkem768.Scheme()), ErrSelfTest)
+	selfTestKATs["ML-KEM-768"] = o
+	o = selfTestKATs["ML-DSA-44"]
+	selfTestKATs["ML-DSA-44"] = "00"
+	assert.ErrorIs(t, sigSelfTest(mldsa44.Scheme()), ErrSelfTest)
+	selfTestKATs["ML-DSA-44"] = o
+
+	// the result is kept
+	st := &selfTest{}
+	calls := 0
+	fail := func() error {
+		calls++
+		return ErrSelfTest
+	}
+	assert.ErrorIs(t, st.run(fail), ErrSelfTest)
+	assert.ErrorIs(t, st.run(fail), ErrSelfTest)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCheckSigHash(t *testing.T) {
+	setFIPSMode(t, false)
+	assert.NoError(t, CheckSigHash(sha1.New()))
+	SetFIPSMode(true)
+	assert.NoError(t, CheckSigHash(nil))
+	assert.NoError(t, CheckSigHash(sha256.New()))
+	assert.NoError(t, CheckSigHash(sha256.New224()))
+	assert.NoError(t, CheckSigHash(sha512.New384()))
+	assert.NoError(t, CheckSigHash(sha512.New512_256()))
+	assert.NoError(t, CheckSigHash(sha3.New512()))
+	assert.ErrorIs(t, CheckSigHash(sha1.New()), ErrHashNotApproved)
+	assert.ErrorIs(t, CheckSigHash(md5.New()), ErrHashNotApproved)
+
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = NewSigData([]byte("data"), time.Now(), time.Now().Add(time.Hour), sha1.New(), k)
+	assert.True(t, errors.Is(err, ErrHashNotApproved))
+	sigData, err := NewSigData([]byte("data"), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	assert.NoError(t, err)
+	v, err := VerifySigData(sigData, sha256.New(), pk)
+	assert.NoError(t, err)
+	assert.True(t, v)
+	_, err = VerifySigData(sigData, md5.New(), pk)
+	assert.ErrorIs(t, err, ErrHashNotApproved)
+}
@@ -0,0 +1,217 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+)
+
+// JWK encoding of ML-DSA and ML-KEM keys using the "AKP" (Algorithm Key Pair) key type from the IETF COSE / JOSE
+// ML-DSA draft, the alg is the scheme name and priv holds the seed the key pair is derived from
+
+const JWKKeyTypeAKP = "AKP"
+
+var ErrInvalidJWK = errors.New("invalid JWK")
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+// JWK is a JSON Web Key of key type "AKP"
+type JWK struct {
+	Kty  string `json:"kty"`
+	Alg  string `json:"alg"`
+	Kid  string `json:"kid,omitempty"`
+	Pub  string `json:"pub"`
+	Priv string `json:"priv,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// akpKey collects the parts of a key needed for JWK and COSE_Key
+type akpKey struct {
+	alg  string
+	pub  []byte
+	priv []byte
+}
+
+// newAKPKey checks key is an ML-KEM / ML-DSA key, for private keys the seed must derive key
+func newAKPKey(key encoding.BinaryMarshaler, seed []byte) (*akpKey, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	name, err := KeySchemeName(key)
+	if err != nil {
+		return nil, err
+	}
+	pub, private := publicOf(key)
+	oid, err := keyOID(key)
+	if err != nil {
+		return nil, ErrUnknownAlgorithm
+	}
+	if !private && seed != nil {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	if private {
+		if seed == nil {
+			return nil, ErrSeedRequired
+		}
+		expanded, err := key.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		derived, err := deriveFromSeed(oid, seed)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(derived, expanded) {
+			return nil, ErrSeedMismatch
+		}
+	}
+	pubBts, err := pub.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &akpKey{alg: name, pub: pubBts, priv: seed}, nil
+}
+
+// publicOf returns the public key of a crypto.KemPrivateKey or crypto.SigPrivateKey and true, other keys are
+// returned as is with false
+func publicOf(key encoding.BinaryMarshaler) (encoding.BinaryMarshaler, bool) {
+	switch k := key.(type) {
+	case crypto.KemPrivateKey:
+		return k.Public(), true
+	case crypto.SigPrivateKey:
+		return k.Public(), true
+	}
+	return key, false
+}
+
+// key returns the public key or, when the seed is present, the private key derived from it
+func (a *akpKey) key() (any, error) {
+	if kem := KemSchemeByName(a.alg); kem != nil && kemOIDs[a.alg] != nil {
+		pk, err := kem.UnmarshalBinaryPublicKey(a.pub)
+		if err != nil || a.priv == nil {
+			return pk, err
+		}
+		dpk, k, err := kem.DeriveKeyPair(a.priv)
+		if err != nil {
+			return nil, err
+		}
+		if !dpk.Equals(pk) {
+			return nil, ErrSeedMismatch
+		}
+		return k, nil
+	}
+	if sig := SigSchemeByName(a.alg); sig != nil && sigOIDs[a.alg] != nil {
+		pk, err := sig.UnmarshalBinaryPublicKey(a.pub)
+		if err != nil || a.priv == nil {
+			return pk, err
+		}
+		dpk, k, err := sig.DeriveKeyPair(a.priv)
+		if err != nil {
+			return nil, err
+		}
+		if !dpk.Equals(pk) {
+			return nil, ErrSeedMismatch
+		}
+		return k, nil
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// NewJWK creates the JWK of an ML-KEM / ML-DSA public key, or of a private key with the seed it was derived from,
+// the kid is set to the RFC 7638 thumbprint
+func NewJWK(key encoding.BinaryMarshaler, seed []byte) (*JWK, error) {
+	a, err := newAKPKey(key, seed)
+	if err != nil {
+		return nil, err
+	}
+	j := &JWK{
+		Kty: JWKKeyTypeAKP,
+		Alg: a.alg,
+		Pub: base64.RawURLEncoding.EncodeToString(a.pub),
+	}
+	if a.priv != nil {
+		j.Priv = base64.RawURLEncoding.EncodeToString(a.priv)
+	}
+	j.Kid, err = j.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Thumbprint returns the base64url SHA-256 RFC 7638 thumbprint over the required members alg, kty and pub
+func (j *JWK) Thumbprint() (string, error) {
+	// json.Marshal of a struct keeps the field order, which is the lexicographic order RFC 7638 requires
+	bts, err := json.Marshal(struct {
+		Alg string `json:"alg"`
+		Kty string `json:"kty"`
+		Pub string `json:"pub"`
+	}{j.Alg, j.Kty, j.Pub})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bts)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Public returns the JWK without the private seed
+func (j *JWK) Public() *JWK {
+	return &JWK{Kty: j.Kty, Alg: j.Alg, Kid: j.Kid, Pub: j.Pub}
+}
+
+// Key returns a crypto.KemPublicKey, crypto.KemPrivateKey, crypto.SigPublicKey or crypto.SigPrivateKey
+func (j *JWK) Key() (any, error) {
+	if j.Kty != JWKKeyTypeAKP {
+		return nil, ErrInvalidJWK
+	}
+	a := &akpKey{alg: j.Alg}
+	var err error
+	a.pub, err = base64.RawURLEncoding.DecodeString(j.Pub)
+	if err != nil {
+		return nil, ErrInvalidJWK
+	}
+	if j.Priv != "" {
+		a.priv, err = base64.RawURLEncoding.DecodeString(j.Priv)
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+	}
+	return a.key()
+}
+
+// MarshalJWK encodes a key as JSON, see NewJWK
+func MarshalJWK(key encoding.BinaryMarshaler, seed []byte) ([]byte, error) {
+	j, err := NewJWK(key, seed)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+// ParseJWK decodes a JSON JWK, see JWK.Key
+func ParseJWK(data []byte) (any, error) {
+	var j JWK
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return j.Key()
+}
+
+// Find returns the first key in the set with the given kid
+func (s *JWKSet) Find(kid string) (*JWK, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,114 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJWKSig(t *testing.T) {
+	for _, name := range []string{"ML-DSA-44", "ML-DSA-65", "ML-DSA-87"} {
+		scheme := SigSchemeByName(name)
+		t.Run(name, func(t *testing.T) {
+			seed := testSeed(scheme.SeedSize())
+			pk, k, err := scheme.DeriveKeyPair(seed)
+			assert.NoError(t, err)
+			pj, err := MarshalJWK(pk, nil)
+			assert.NoError(t, err)
+			rpk, err := ParseJWK(pj)
+			assert.NoError(t, err)
+			assert.True(t, pk.Equals(rpk.(crypto.SigPublicKey)))
+			kj, err := MarshalJWK(k, seed)
+			assert.NoError(t, err)
+			rk, err := ParseJWK(kj)
+			assert.NoError(t, err)
+			assert.True(t, k.Equals(rk.(crypto.SigPrivateKey)))
+
+			var pjwk, kjwk JWK
+			assert.NoError(t, json.Unmarshal(pj, &pjwk))
+			assert.NoError(t, json.Unmarshal(kj, &kjwk))
+			assert.Equal(t, "AKP", pjwk.Kty)
+			assert.Equal(t, name, pjwk.Alg)
+			assert.Empty(t, pjwk.Priv)
+			assert.Equal(t, pjwk.Kid, kjwk.Kid)
+			assert.Equal(t, pjwk, *kjwk.Public())
+		})
+	}
+}
+
+func TestJWKKem(t *testing.T) {
+	scheme := KemSchemeByName("ML-KEM-768")
+	seed := testSeed(scheme.SeedSize())
+	pk, k, err := scheme.DeriveKeyPair(seed)
+	assert.NoError(t, err)
+	j, err := NewJWK(k, seed)
+	assert.NoError(t, err)
+	assert.Equal(t, "ML-KEM-768", j.Alg)
+	rk, err := j.Key()
+	assert.NoError(t, err)
+	assert.True(t, k.Equals(rk.(crypto.KemPrivateKey)))
+	rpk, err := j.Public().Key()
+	assert.NoError(t, err)
+	assert.True(t, pk.Equals(rpk.(crypto.KemPublicKey)))
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	pk, _, err := WrapSig(mldsa44.Scheme()).DeriveKeyPair(testSeed(32))
+	assert.NoError(t, err)
+	bts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+	j, err := NewJWK(pk, nil)
+	assert.NoError(t, err)
+	sum := sha256.Sum256([]byte(`{"alg":"ML-DSA-44","kty":"AKP","pub":"` + base64.RawURLEncoding.EncodeToString(bts) + `"}`))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), j.Kid)
+
+	set := JWKSet{Keys: []JWK{*j}}
+	found, ok := set.Find(j.Kid)
+	assert.True(t, ok)
+	assert.Equal(t, j, found)
+	_, ok = set.Find("missing")
+	assert.False(t, ok)
+}
+
+func TestJWKInvalid(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	seed := testSeed(scheme.SeedSize())
+	pk, k, err := scheme.DeriveKeyPair(seed)
+	assert.NoError(t, err)
+	_, err = NewJWK(k, nil)
+	assert.ErrorIs(t, err, ErrSeedRequired)
+	_, err = NewJWK(pk, seed)
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+	seed[0] ^= 0xff
+	_, err = NewJWK(k, seed)
+	assert.ErrorIs(t, err, ErrSeedMismatch)
+
+	kpk, _, err := WrapKem(kyber768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = NewJWK(kpk, nil)
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	j, err := NewJWK(pk, nil)
+	assert.NoError(t, err)
+	other, _, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	otherBts, err := other.MarshalBinary()
+	assert.NoError(t, err)
+	j.Priv = base64.RawURLEncoding.EncodeToString(testSeed(32))
+	j.Pub = base64.RawURLEncoding.EncodeToString(otherBts)
+	_, err = j.Key()
+	assert.ErrorIs(t, err, ErrSeedMismatch)
+	_, err = ParseJWK([]byte(`{"kty":"EC","alg":"ML-DSA-44","pub":""}`))
+	assert.ErrorIs(t, err, ErrInvalidJWK)
+	_, err = ParseJWK([]byte(`{"kty":"AKP","alg":"ML-DSA-44","pub":"!"}`))
+	assert.ErrorIs(t, err, ErrInvalidJWK)
+	_, err = ParseJWK([]byte(`{"kty":"AKP","alg":"Kyber768","pub":""}`))
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
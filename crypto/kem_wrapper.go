@@ -3,45 +3,82 @@
 package crypto
 
 import (
+	"fmt"
 	"github.com/1f349/handshake/crypto"
 	"github.com/cloudflare/circl/kem"
+	"strings"
 	"sync"
 )
 
-var kemWrappedMap = make(map[kem.Scheme]*KemWrapper)
-var slockKemWrappedMap = &sync.RWMutex{}
+// kemWrappedMap maps kem.Scheme to *KemWrapper, sync.Map is used as entries are only ever added once and then
+// read on every call to WrapKem and Scheme()
+var kemWrappedMap = &sync.Map{}
+
+// kemNamedMap maps the lower case scheme name to *KemWrapper for KemSchemeByName
+var kemNamedMap = &sync.Map{}
 
 func getKemWrapper(scheme kem.Scheme) *KemWrapper {
-	slockKemWrappedMap.RLock()
-	defer slockKemWrappedMap.RUnlock()
-	kemWrapped, ok := kemWrappedMap[scheme]
-	if !ok {
-		return nil
+	if w, ok := kemWrappedMap.Load(scheme); ok {
+		return w.(*KemWrapper)
 	}
-	return kemWrapped
+	return nil
+}
+
+// WrapKem a kem.Scheme, the same *KemWrapper is returned for every call with the same scheme. This never fails, in
+// FIPS mode the wrapper methods return ErrNotFIPSApproved for schemes that are not approved, see TryWrapKem
+func WrapKem(scheme kem.Scheme) *KemWrapper {
+	return registerKem(scheme)
 }
 
-func addKemWrapper(scheme kem.Scheme) *KemWrapper {
-	slockKemWrappedMap.Lock()
-	defer slockKemWrappedMap.Unlock()
-	if _, ok := kemWrappedMap[scheme]; !ok {
-		kemWrappedMap[scheme] = &KemWrapper{scheme}
+// TryWrapKem is WrapKem returning ErrNotFIPSApproved in FIPS mode for schemes that are not approved or fail their
+// self-test
+func TryWrapKem(scheme kem.Scheme) (*KemWrapper, error) {
+	w := registerKem(scheme)
+	if FIPSMode() {
+		if err := w.fipsCheck(); err != nil {
+			return nil, err
+		}
 	}
-	return kemWrappedMap[scheme]
+	return w, nil
 }
 
-// WrapKem a kem.Scheme
-func WrapKem(scheme kem.Scheme) *KemWrapper {
-	w := getKemWrapper(scheme)
-	if w == nil {
-		return addKemWrapper(scheme)
+// registerKem returns the registered wrapper for scheme, adding it if needed, without any FIPS mode checks
+func registerKem(scheme kem.Scheme) *KemWrapper {
+	if w := getKemWrapper(scheme); w != nil {
+		return w
+	}
+	w, loaded := kemWrappedMap.LoadOrStore(scheme, &KemWrapper{
+		wrapped:  scheme,
+		selfTest: &selfTest{},
+	})
+	if !loaded {
+		kemNamedMap.LoadOrStore(strings.ToLower(scheme.Name()), w)
+	}
+	return w.(*KemWrapper)
+}
+
+// fipsCheck refuses schemes that are not approved and runs the self-test once
+func (k *KemWrapper) fipsCheck() error {
+	if !fipsApproved(k.Name()) {
+		return fmt.Errorf("%w: %s", ErrNotFIPSApproved, k.Name())
+	}
+	return k.selfTest.run(func() error {
+		return kemSelfTest(k.wrapped)
+	})
+}
+
+// modeCheck is fipsCheck in FIPS mode, so wrappers made before FIPS mode was turned on or with WrapKem are refused too
+func (k *KemWrapper) modeCheck() error {
+	if !FIPSMode() {
+		return nil
 	}
-	return w
+	return k.fipsCheck()
 }
 
 // KemWrapper wraps kem.Scheme from github.com/cloudflare/circl for KemScheme
 type KemWrapper struct {
-	wrapped kem.Scheme
+	wrapped  kem.Scheme
+	selfTest *selfTest
 }
 
 func (k KemWrapper) Name() string {
@@ -49,6 +86,9 @@ func (k KemWrapper) Name() string {
 }
 
 func (k KemWrapper) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, nil, err
+	}
 	p, q, err := k.wrapped.GenerateKeyPair()
 	if err != nil {
 		return nil, nil, err
@@ -56,7 +96,22 @@ func (k KemWrapper) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey
 	return &KemPublicKeyWrapper{p}, &KemPrivateKeyWrapper{q}, nil
 }
 
+// DeriveKeyPair deterministically derives a key pair from a seed of SeedSize bytes
+func (k KemWrapper) DeriveKeyPair(seed []byte) (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, nil, err
+	}
+	if len(seed) != k.wrapped.SeedSize() {
+		return nil, nil, kem.ErrSeedSize
+	}
+	p, q := k.wrapped.DeriveKeyPair(seed)
+	return &KemPublicKeyWrapper{p}, &KemPrivateKeyWrapper{q}, nil
+}
+
 func (k KemWrapper) Encapsulate(key crypto.KemPublicKey) (ctxt, secret []byte, err error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, nil, err
+	}
 	if key == nil {
 		return nil, nil, crypto.ErrKeyNil
 	}
@@ -67,6 +122,9 @@ func (k KemWrapper) Encapsulate(key crypto.KemPublicKey) (ctxt, secret []byte, e
 }
 
 func (k KemWrapper) Decapsulate(key crypto.KemPrivateKey, ctxt []byte) ([]byte, error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, err
+	}
 	if key == nil {
 		return nil, crypto.ErrKeyNil
 	}
@@ -77,6 +135,9 @@ func (k KemWrapper) Decapsulate(key crypto.KemPrivateKey, ctxt []byte) ([]byte,
 }
 
 func (k KemWrapper) UnmarshalBinaryPrivateKey(bytes []byte) (crypto.KemPrivateKey, error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, err
+	}
 	wk, err := k.wrapped.UnmarshalBinaryPrivateKey(bytes)
 	if err != nil {
 		return nil, err
@@ -85,6 +146,9 @@ func (k KemWrapper) UnmarshalBinaryPrivateKey(bytes []byte) (crypto.KemPrivateKe
 }
 
 func (k KemWrapper) UnmarshalBinaryPublicKey(bytes []byte) (crypto.KemPublicKey, error) {
+	if err := k.modeCheck(); err != nil {
+		return nil, err
+	}
 	wk, err := k.wrapped.UnmarshalBinaryPublicKey(bytes)
 	if err != nil {
 		return nil, err
@@ -108,13 +172,17 @@ func (k KemWrapper) PublicKeySize() int {
 	return k.wrapped.PublicKeySize()
 }
 
+func (k KemWrapper) SeedSize() int {
+	return k.wrapped.SeedSize()
+}
+
 // KemPublicKeyWrapper wraps kem.PublicKey  for KemPublicKey
 type KemPublicKeyWrapper struct {
 	kem.PublicKey
 }
 
 func (k KemPublicKeyWrapper) Scheme() crypto.KemScheme {
-	return getKemWrapper(k.PublicKey.Scheme())
+	return registerKem(k.PublicKey.Scheme())
 }
 
 func (k KemPublicKeyWrapper) Equals(key crypto.KemPublicKey) bool {
@@ -133,7 +201,7 @@ type KemPrivateKeyWrapper struct {
 }
 
 func (k KemPrivateKeyWrapper) Scheme() crypto.KemScheme {
-	return getKemWrapper(k.PrivateKey.Scheme())
+	return registerKem(k.PrivateKey.Scheme())
 }
 
 func (k KemPrivateKeyWrapper) Equals(key crypto.KemPrivateKey) bool {
@@ -0,0 +1,270 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"sync"
+	"time"
+)
+
+var ErrKeyPoolClosed = errors.New("key pool closed")
+
+// DefaultKeyPoolSize is the number of key pairs kept ready when KeyPoolConfig.Size is 0
+const DefaultKeyPoolSize = 16
+
+// keyPoolRetry is how long a refill goroutine waits after key generation fails
+const keyPoolRetry = 100 * time.Millisecond
+
+// KeyPoolConfig configures a KeyPool
+type KeyPoolConfig struct {
+	// Size is the number of key pairs kept ready, DefaultKeyPoolSize if 0
+	Size int
+	// Workers is the number of goroutines refilling the pool, 1 if 0
+	Workers int
+	// MaxAge is how long a key pair may wait in the pool before it is destroyed, 0 for no limit
+	MaxAge time.Duration
+	// Now is the clock used for key ages, time.Now if nil
+	Now func() time.Time
+	// Hooks are called for pool events, nil hooks are skipped
+	Hooks KeyPoolHooks
+}
+
+// KeyPoolHooks report pool events for metrics, they are called without the pool lock held and must be safe for
+// concurrent use
+type KeyPoolHooks struct {
+	// OnGenerate is called after every key generation in the background with how long it took
+	OnGenerate func(d time.Duration, err error)
+	// OnServe is called for every key handed out, pooled is false if the pool was empty and the key was generated
+	// on demand
+	OnServe func(pooled bool)
+	// OnDestroy is called for every key destroyed unused, when too old or when the pool is closed
+	OnDestroy func()
+}
+
+// KeyPoolStats counts pool events
+type KeyPoolStats struct {
+	Ready     int
+	Generated uint64
+	Failed    uint64
+	Served    uint64
+	Misses    uint64
+	Destroyed uint64
+}
+
+type pooledKey struct {
+	public  crypto.KemPublicKey
+	private crypto.KemPrivateKey
+	created time.Time
+}
+
+// KeyPool keeps key pairs of a scheme generated ahead of time so they are ready when needed, each key pair is handed
+// out once
+type KeyPool struct {
+	scheme  crypto.KemScheme
+	size    int
+	maxAge  time.Duration
+	now     func() time.Time
+	hooks   KeyPoolHooks
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ready   []*pooledKey
+	pending int
+	closed  bool
+	stats   KeyPoolStats
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewKeyPool starts filling a pool of key pairs of scheme, Close stops it
+func NewKeyPool(scheme crypto.KemScheme, config KeyPoolConfig) *KeyPool {
+	if config.Size <= 0 {
+		config.Size = DefaultKeyPoolSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	p := &KeyPool{
+		scheme: scheme,
+		size:   config.Size,
+		maxAge: config.MaxAge,
+		now:    config.Now,
+		hooks:  config.Hooks,
+		ready:  make([]*pooledKey, 0, config.Size),
+		stop:   make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(config.Workers)
+	for range config.Workers {
+		go p.refill()
+	}
+	if p.maxAge > 0 {
+		p.wg.Add(1)
+		go p.expireLoop()
+	}
+	return p
+}
+
+func (p *KeyPool) refill() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for !p.closed && len(p.ready)+p.pending >= p.size {
+			p.cond.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		p.pending++
+		p.mu.Unlock()
+
+		start := time.Now()
+		pk, sk, err := p.scheme.GenerateKeyPair()
+		if p.hooks.OnGenerate != nil {
+			p.hooks.OnGenerate(time.Since(start), err)
+		}
+
+		p.mu.Lock()
+		p.pending--
+		if err != nil {
+			p.stats.Failed++
+			p.mu.Unlock()
+			select {
+			case <-p.stop:
+			case <-time.After(keyPoolRetry):
+			}
+			continue
+		}
+		p.stats.Generated++
+		if p.closed {
+			p.stats.Destroyed++
+			p.mu.Unlock()
+			p.destroy(&pooledKey{public: pk, private: sk})
+			return
+		}
+		p.ready = append(p.ready, &pooledKey{public: pk, private: sk, created: p.now()})
+		p.mu.Unlock()
+	}
+}
+
+func (p *KeyPool) expireLoop() {
+	defer p.wg.Done()
+	t := time.NewTicker(max(p.maxAge/4, time.Millisecond))
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.Expire()
+		}
+	}
+}
+
+// expired removes the expired keys from the front of the pool, p.mu must be held
+func (p *KeyPool) expired() []*pooledKey {
+	if p.maxAge <= 0 {
+		return nil
+	}
+	now := p.now()
+	n := 0
+	for n < len(p.ready) && now.Sub(p.ready[n].created) > p.maxAge {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	old := append([]*pooledKey(nil), p.ready[:n]...)
+	p.ready = append(p.ready[:0], p.ready[n:]...)
+	p.stats.Destroyed += uint64(n)
+	p.cond.Broadcast()
+	return old
+}
+
+// Expire destroys the key pairs older than the maximum age now, this also runs in the background
+func (p *KeyPool) Expire() {
+	p.mu.Lock()
+	old := p.expired()
+	p.mu.Unlock()
+	for _, k := range old {
+		p.destroy(k)
+	}
+}
+
+// destroy drops the pool's references to the keys held by k so they can be garbage collected, circl does not allow
+// zeroing the key material so it stays in memory until then
+func (p *KeyPool) destroy(k *pooledKey) {
+	k.private, k.public = nil, nil
+	if p.hooks.OnDestroy != nil {
+		p.hooks.OnDestroy()
+	}
+}
+
+// GenerateKeyPair hands out the oldest ready key pair, or generates one if the pool is empty, so KeyPool can be used
+// in place of the scheme
+func (p *KeyPool) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, nil, ErrKeyPoolClosed
+	}
+	old := p.expired()
+	var k *pooledKey
+	if len(p.ready) > 0 {
+		k = p.ready[0]
+		p.ready[0] = nil
+		p.ready = p.ready[1:]
+		p.stats.Served++
+		p.cond.Signal()
+	} else {
+		p.stats.Misses++
+	}
+	p.mu.Unlock()
+	for _, o := range old {
+		p.destroy(o)
+	}
+	if p.hooks.OnServe != nil {
+		p.hooks.OnServe(k != nil)
+	}
+	if k != nil {
+		return k.public, k.private, nil
+	}
+	return p.scheme.GenerateKeyPair()
+}
+
+// Stats returns the counts so far
+func (p *KeyPool) Stats() KeyPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Ready = len(p.ready)
+	return s
+}
+
+// Close stops refilling, waits for the background goroutines and destroys the ready key pairs
+func (p *KeyPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	p.mu.Lock()
+	old := p.ready
+	p.ready = nil
+	p.stats.Destroyed += uint64(len(old))
+	p.mu.Unlock()
+	for _, k := range old {
+		p.destroy(k)
+	}
+}
@@ -0,0 +1,140 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedKemScheme only generates keys while open
+type gatedKemScheme struct {
+	crypto.KemScheme
+	gate chan struct{}
+}
+
+func newGatedKemScheme(open bool) *gatedKemScheme {
+	s := &gatedKemScheme{KemScheme: WrapKem(mlkem768.Scheme()), gate: make(chan struct{})}
+	if open {
+		close(s.gate)
+	}
+	return s
+}
+
+func (s *gatedKemScheme) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	<-s.gate
+	return s.KemScheme.GenerateKeyPair()
+}
+
+func (s *gatedKemScheme) open() {
+	close(s.gate)
+}
+
+func waitReady(t *testing.T, p *KeyPool, n int) {
+	assert.Eventually(t, func() bool {
+		return p.Stats().Ready == n
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestKeyPool(t *testing.T) {
+	var generated, served, pooled, destroyed atomic.Int64
+	p := NewKeyPool(WrapKem(mlkem768.Scheme()), KeyPoolConfig{
+		Size:    4,
+		Workers: 2,
+		Hooks: KeyPoolHooks{
+			OnGenerate: func(d time.Duration, err error) {
+				assert.NoError(t, err)
+				generated.Add(1)
+			},
+			OnServe: func(p bool) {
+				served.Add(1)
+				if p {
+					pooled.Add(1)
+				}
+			},
+			OnDestroy: func() {
+				destroyed.Add(1)
+			},
+		},
+	})
+	waitReady(t, p, 4)
+
+	seen := make(map[string]bool)
+	for range 10 {
+		pk, sk, err := p.GenerateKeyPair()
+		assert.NoError(t, err)
+		assert.True(t, sk.Public().Equals(pk))
+		bts, err := pk.MarshalBinary()
+		assert.NoError(t, err)
+		assert.False(t, seen[string(bts)])
+		seen[string(bts)] = true
+	}
+	waitReady(t, p, 4)
+	s := p.Stats()
+	assert.Equal(t, uint64(10), s.Served+s.Misses)
+	assert.Equal(t, int64(10), served.Load())
+	assert.Equal(t, int64(s.Served), pooled.Load())
+	assert.Equal(t, int64(s.Generated), generated.Load())
+
+	p.Close()
+	p.Close()
+	_, _, err := p.GenerateKeyPair()
+	assert.ErrorIs(t, err, ErrKeyPoolClosed)
+	assert.Equal(t, 0, p.Stats().Ready)
+	assert.Equal(t, int64(p.Stats().Destroyed), destroyed.Load())
+	assert.GreaterOrEqual(t, destroyed.Load(), int64(4))
+}
+
+func TestKeyPoolEmpty(t *testing.T) {
+	scheme := newGatedKemScheme(false)
+	p := NewKeyPool(scheme, KeyPoolConfig{Size: 2})
+	defer p.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, err := p.GenerateKeyPair()
+		assert.NoError(t, err)
+	}()
+	// the pool is empty so the key is generated on demand once generation can run
+	assert.Eventually(t, func() bool {
+		return p.Stats().Misses == 1
+	}, 5*time.Second, time.Millisecond)
+	scheme.open()
+	<-done
+	waitReady(t, p, 2)
+}
+
+func TestKeyPoolMaxAge(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Now()
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	p := NewKeyPool(WrapKem(mlkem768.Scheme()), KeyPoolConfig{Size: 3, MaxAge: time.Hour, Now: clock})
+	defer p.Close()
+	waitReady(t, p, 3)
+	pk, sk, err := p.GenerateKeyPair()
+	assert.NoError(t, err)
+	waitReady(t, p, 3)
+	// keys already handed out are not destroyed
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	mu.Unlock()
+	p.Expire()
+	assert.GreaterOrEqual(t, p.Stats().Destroyed, uint64(3))
+	assert.True(t, sk.Public().Equals(pk))
+	waitReady(t, p, 3)
+
+	// the pool refills with fresh keys
+	pk, sk, err = p.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NotNil(t, sk.(*KemPrivateKeyWrapper).PrivateKey)
+	assert.NotNil(t, pk.(*KemPublicKeyWrapper).PublicKey)
+}
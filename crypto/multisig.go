@@ -0,0 +1,192 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"math"
+	"time"
+)
+
+// A multi-signature container holds one public key with a validity window and independent signatures from several
+// signers, each made as crypto.NewSigData would over the same data and times. It is accepted once a threshold of
+// distinct trusted signers verify:
+//
+//	"PQCMS" | version 1 | issued unix ms | expiry unix ms | len u32 | data | count u8 | count * (len u32 | tagged signer | len u32 | signature)
+
+var ErrInvalidMultiSig = errors.New("invalid multi-signature")
+
+const multiSigMagic = "PQCMS\x01"
+
+// MultiSignature is a signature in a MultiSigData
+type MultiSignature struct {
+	Signer    crypto.SigPublicKey
+	Signature []byte
+}
+
+// MultiSigData is crypto.SigData with any number of signatures
+type MultiSigData struct {
+	PublicKey  []byte
+	IssueTime  time.Time
+	ExpiryTime time.Time
+	Signatures []MultiSignature
+}
+
+// NewMultiSigData creates an unsigned container for data, the times are truncated to milliseconds as they are signed
+func NewMultiSigData(data []byte, issue, expiry time.Time) *MultiSigData {
+	return &MultiSigData{
+		PublicKey:  data,
+		IssueTime:  time.UnixMilli(issue.UnixMilli()),
+		ExpiryTime: time.UnixMilli(expiry.UnixMilli()),
+	}
+}
+
+// sigData is the crypto.SigData of one signature
+func (m *MultiSigData) sigData(signature []byte) *crypto.SigData {
+	return &crypto.SigData{PublicKey: m.PublicKey, IssueTime: m.IssueTime, ExpiryTime: m.ExpiryTime, Signature: signature}
+}
+
+// Sign adds the signature of key, replacing an existing signature by the same key
+func (m *MultiSigData) Sign(h hash.Hash, key crypto.SigPrivateKey) error {
+	if key == nil {
+		return crypto.ErrKeyNil
+	}
+	sigData, err := NewSigData(m.PublicKey, m.IssueTime, m.ExpiryTime, h, key)
+	if err != nil {
+		return err
+	}
+	if sigData == nil {
+		return ErrInvalidMultiSig
+	}
+	signer := key.Public()
+	for i, s := range m.Signatures {
+		if s.Signer.Equals(signer) {
+			m.Signatures[i].Signature = sigData.Signature
+			return nil
+		}
+	}
+	if len(m.Signatures) == math.MaxUint8 {
+		return ErrInvalidMultiSig
+	}
+	m.Signatures = append(m.Signatures, MultiSignature{Signer: signer, Signature: sigData.Signature})
+	return nil
+}
+
+// ValidSigners returns the distinct keys in trusted with a valid signature, see crypto.SigData.Verify
+func (m *MultiSigData) ValidSigners(h hash.Hash, trusted []crypto.SigPublicKey) []crypto.SigPublicKey {
+	var valid []crypto.SigPublicKey
+	for i, key := range trusted {
+		if key == nil || containsSigKey(trusted[:i], key) {
+			continue
+		}
+		for _, s := range m.Signatures {
+			if s.Signer == nil || !s.Signer.Equals(key) {
+				continue
+			}
+			if ok, err := VerifySigData(m.sigData(s.Signature), h, key); err == nil && ok {
+				valid = append(valid, key)
+				break
+			}
+		}
+	}
+	return valid
+}
+
+// Verify checks at least threshold distinct keys in trusted have a valid signature
+func (m *MultiSigData) Verify(h hash.Hash, trusted []crypto.SigPublicKey, threshold int) bool {
+	return threshold > 0 && len(m.ValidSigners(h, trusted)) >= threshold
+}
+
+func containsSigKey(keys []crypto.SigPublicKey, key crypto.SigPublicKey) bool {
+	for _, k := range keys {
+		if k != nil && k.Equals(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendMultiSigPart(b, part []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(part)))
+	return append(b, part...)
+}
+
+func readMultiSigPart(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, ErrInvalidMultiSig
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n) > uint64(len(b)-4) {
+		return nil, nil, ErrInvalidMultiSig
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
+
+// MarshalBinary encodes the container including the data
+func (m *MultiSigData) MarshalBinary() ([]byte, error) {
+	if len(m.Signatures) > math.MaxUint8 || len(m.PublicKey) > math.MaxUint32 {
+		return nil, ErrInvalidMultiSig
+	}
+	b := []byte(multiSigMagic)
+	b = binary.BigEndian.AppendUint64(b, uint64(m.IssueTime.UnixMilli()))
+	b = binary.BigEndian.AppendUint64(b, uint64(m.ExpiryTime.UnixMilli()))
+	b = appendMultiSigPart(b, m.PublicKey)
+	b = append(b, byte(len(m.Signatures)))
+	for _, s := range m.Signatures {
+		signer, err := MarshalTagged(s.Signer)
+		if err != nil {
+			return nil, err
+		}
+		b = appendMultiSigPart(b, signer)
+		b = appendMultiSigPart(b, s.Signature)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary, the signer schemes are found with SigSchemeByName
+func (m *MultiSigData) UnmarshalBinary(b []byte) error {
+	if !bytes.HasPrefix(b, []byte(multiSigMagic)) || len(b) < len(multiSigMagic)+16 {
+		return ErrInvalidMultiSig
+	}
+	b = b[len(multiSigMagic):]
+	issue := time.UnixMilli(int64(binary.BigEndian.Uint64(b)))
+	expiry := time.UnixMilli(int64(binary.BigEndian.Uint64(b[8:])))
+	data, b, err := readMultiSigPart(b[16:])
+	if err != nil {
+		return err
+	}
+	if len(b) < 1 {
+		return ErrInvalidMultiSig
+	}
+	count := int(b[0])
+	b = b[1:]
+	signatures := make([]MultiSignature, 0, count)
+	for range count {
+		var tagged, sig []byte
+		tagged, b, err = readMultiSigPart(b)
+		if err != nil {
+			return err
+		}
+		sig, b, err = readMultiSigPart(b)
+		if err != nil {
+			return err
+		}
+		signer, err := UnmarshalAnySigPublicKey(tagged)
+		if err != nil {
+			return err
+		}
+		signatures = append(signatures, MultiSignature{Signer: signer, Signature: bytes.Clone(sig)})
+	}
+	if len(b) != 0 {
+		return ErrInvalidMultiSig
+	}
+	m.PublicKey = bytes.Clone(data)
+	m.IssueTime = issue
+	m.ExpiryTime = expiry
+	m.Signatures = signatures
+	return nil
+}
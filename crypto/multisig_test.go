@@ -0,0 +1,76 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMultiSigData(t *testing.T) {
+	kemPk, _, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	data, err := kemPk.MarshalBinary()
+	assert.NoError(t, err)
+	pks := make([]crypto.SigPublicKey, 3)
+	ks := make([]crypto.SigPrivateKey, 3)
+	for i := range pks {
+		scheme := WrapSig(mldsa44.Scheme())
+		if i == 2 {
+			scheme = WrapSig(mldsa65.Scheme())
+		}
+		pks[i], ks[i], err = scheme.GenerateKeyPair()
+		assert.NoError(t, err)
+	}
+	untrustedPk, untrusted, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+
+	m := NewMultiSigData(data, time.Now(), time.Now().Add(time.Hour))
+	assert.False(t, m.Verify(sha256.New(), pks, 1))
+	assert.NoError(t, m.Sign(sha256.New(), ks[0]))
+	assert.NoError(t, m.Sign(sha256.New(), untrusted))
+	assert.True(t, m.Verify(sha256.New(), pks, 1))
+	assert.False(t, m.Verify(sha256.New(), pks, 2))
+
+	// signing again replaces the signature and trusting a key twice doesn't count it twice
+	assert.NoError(t, m.Sign(sha256.New(), ks[0]))
+	assert.Len(t, m.Signatures, 2)
+	assert.False(t, m.Verify(sha256.New(), []crypto.SigPublicKey{pks[0], pks[0]}, 2))
+
+	assert.NoError(t, m.Sign(sha256.New(), ks[2]))
+	assert.True(t, m.Verify(sha256.New(), pks, 2))
+	assert.False(t, m.Verify(sha256.New(), pks, 3))
+	assert.True(t, m.Verify(sha256.New(), append(pks, untrustedPk), 3))
+	assert.False(t, m.Verify(sha256.New(), pks, 0))
+	assert.False(t, m.Verify(nil, pks, 1))
+
+	bts, err := m.MarshalBinary()
+	assert.NoError(t, err)
+	parsed := &MultiSigData{}
+	assert.NoError(t, parsed.UnmarshalBinary(bts))
+	assert.Equal(t, data, parsed.PublicKey)
+	assert.True(t, m.IssueTime.Equal(parsed.IssueTime))
+	assert.Len(t, parsed.Signatures, 3)
+	assert.Len(t, parsed.ValidSigners(sha256.New(), pks), 2)
+
+	// a damaged signature or changed window no longer counts
+	parsed.Signatures[0].Signature[0] ^= 1
+	assert.Len(t, parsed.ValidSigners(sha256.New(), pks), 1)
+	parsed.ExpiryTime = parsed.ExpiryTime.Add(time.Hour)
+	assert.Empty(t, parsed.ValidSigners(sha256.New(), pks))
+
+	expired := NewMultiSigData(data, time.Now().Add(-time.Hour), time.Now().Add(-time.Minute))
+	assert.NoError(t, expired.Sign(sha256.New(), ks[0]))
+	assert.False(t, expired.Verify(sha256.New(), pks, 1))
+
+	assert.ErrorIs(t, parsed.UnmarshalBinary(bts[:len(bts)-1]), ErrInvalidMultiSig)
+	assert.ErrorIs(t, parsed.UnmarshalBinary(append(bts, 0)), ErrInvalidMultiSig)
+	assert.ErrorIs(t, parsed.UnmarshalBinary([]byte("hello")), ErrInvalidMultiSig)
+	assert.ErrorIs(t, m.Sign(sha256.New(), nil), crypto.ErrKeyNil)
+}
@@ -0,0 +1,319 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+)
+
+// OIDs from the IETF LAMPS ML-KEM and ML-DSA certificate drafts (NIST CSOR)
+var (
+	OIDMLKEM512  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 1}
+	OIDMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+	OIDMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+	OIDMLDSA44   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+	OIDMLDSA65   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+	OIDMLDSA87   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+)
+
+var kemOIDs = map[string]asn1.ObjectIdentifier{
+	"ML-KEM-512":  OIDMLKEM512,
+	"ML-KEM-768":  OIDMLKEM768,
+	"ML-KEM-1024": OIDMLKEM1024,
+}
+
+var sigOIDs = map[string]asn1.ObjectIdentifier{
+	"ML-DSA-44": OIDMLDSA44,
+	"ML-DSA-65": OIDMLDSA65,
+	"ML-DSA-87": OIDMLDSA87,
+}
+
+const (
+	PEMPublicKeyType  = "PUBLIC KEY"
+	PEMPrivateKeyType = "PRIVATE KEY"
+)
+
+var ErrUnknownOID = errors.New("unknown algorithm OID")
+var ErrInvalidPKIX = errors.New("invalid PKIX encoding")
+var ErrInvalidPEM = errors.New("invalid PEM block")
+var ErrSeedRequired = errors.New("seed required for private key form")
+var ErrSeedMismatch = errors.New("seed does not match private key")
+
+// PrivateKeyForm selects the ML-KEM / ML-DSA private key CHOICE used in PKCS#8
+type PrivateKeyForm int
+
+const (
+	// PrivateKeyExpanded stores only the expanded private key
+	PrivateKeyExpanded PrivateKeyForm = iota
+	// PrivateKeySeed stores only the seed, the key is derived from it when parsed
+	PrivateKeySeed
+	// PrivateKeyBoth stores the seed and the expanded private key
+	PrivateKeyBoth
+)
+
+type pkixPublicKey struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// pkcs8 is OneAsymmetricKey (RFC 5958)
+type pkcs8 struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	Attributes asn1.RawValue  `asn1:"optional,tag:0"`
+	PublicKey  asn1.BitString `asn1:"optional,tag:1"`
+}
+
+type pkcs8Both struct {
+	Seed        []byte
+	ExpandedKey []byte
+}
+
+// OIDKemScheme returns the wrapper for the ML-KEM scheme with the given OID or nil
+func OIDKemScheme(oid asn1.ObjectIdentifier) *KemWrapper {
+	for name, o := range kemOIDs {
+		if o.Equal(oid) {
+			return KemSchemeByName(name)
+		}
+	}
+	return nil
+}
+
+// OIDSigScheme returns the wrapper for the ML-DSA scheme with the given OID or nil
+func OIDSigScheme(oid asn1.ObjectIdentifier) *SigWrapper {
+	for name, o := range sigOIDs {
+		if o.Equal(oid) {
+			return SigSchemeByName(name)
+		}
+	}
+	return nil
+}
+
+func keyOID(key encoding.BinaryMarshaler) (asn1.ObjectIdentifier, error) {
+	name, err := KeySchemeName(key)
+	if err != nil {
+		return nil, err
+	}
+	var oid asn1.ObjectIdentifier
+	switch key.(type) {
+	case crypto.KemPublicKey, crypto.KemPrivateKey:
+		oid = kemOIDs[name]
+	default:
+		oid = sigOIDs[name]
+	}
+	if oid == nil {
+		return nil, ErrUnknownOID
+	}
+	return oid, nil
+}
+
+// MarshalPKIXPublicKey encodes a crypto.KemPublicKey or crypto.SigPublicKey as DER SubjectPublicKeyInfo
+func MarshalPKIXPublicKey(key encoding.BinaryMarshaler) ([]byte, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	switch key.(type) {
+	case crypto.KemPublicKey, crypto.SigPublicKey:
+	default:
+		return nil, crypto.ErrIncompatibleKey
+	}
+	oid, err := keyOID(key)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkixPublicKey{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oid},
+		PublicKey: asn1.BitString{Bytes: bts, BitLength: 8 * len(bts)},
+	})
+}
+
+// ParsePKIXPublicKey decodes DER SubjectPublicKeyInfo into a crypto.KemPublicKey or crypto.SigPublicKey
+func ParsePKIXPublicKey(der []byte) (any, error) {
+	var spki pkixPublicKey
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || len(spki.Algorithm.Parameters.FullBytes) != 0 || spki.PublicKey.BitLength%8 != 0 {
+		return nil, ErrInvalidPKIX
+	}
+	if scheme := OIDKemScheme(spki.Algorithm.Algorithm); scheme != nil {
+		return scheme.UnmarshalBinaryPublicKey(spki.PublicKey.Bytes)
+	}
+	if scheme := OIDSigScheme(spki.Algorithm.Algorithm); scheme != nil {
+		return scheme.UnmarshalBinaryPublicKey(spki.PublicKey.Bytes)
+	}
+	return nil, ErrUnknownOID
+}
+
+// MarshalPKCS8PrivateKey encodes a crypto.KemPrivateKey or crypto.SigPrivateKey as DER PKCS#8 OneAsymmetricKey,
+// seed is required for PrivateKeySeed and PrivateKeyBoth and must derive key
+func MarshalPKCS8PrivateKey(key encoding.BinaryMarshaler, seed []byte, form PrivateKeyForm) ([]byte, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	switch key.(type) {
+	case crypto.KemPrivateKey, crypto.SigPrivateKey:
+	default:
+		return nil, crypto.ErrIncompatibleKey
+	}
+	oid, err := keyOID(key)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if form != PrivateKeyExpanded {
+		if seed == nil {
+			return nil, ErrSeedRequired
+		}
+		derived, err := deriveFromSeed(oid, seed)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(derived, expanded) {
+			return nil, ErrSeedMismatch
+		}
+	}
+	var inner []byte
+	switch form {
+	case PrivateKeyExpanded:
+		inner, err = asn1.Marshal(expanded)
+	case PrivateKeySeed:
+		inner, err = asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: seed})
+	case PrivateKeyBoth:
+		inner, err = asn1.Marshal(pkcs8Both{Seed: seed, ExpandedKey: expanded})
+	default:
+		return nil, ErrInvalidPKIX
+	}
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oid},
+		PrivateKey: inner,
+	})
+}
+
+// deriveFromSeed returns the expanded private key derived from seed
+func deriveFromSeed(oid asn1.ObjectIdentifier, seed []byte) ([]byte, error) {
+	if scheme := OIDKemScheme(oid); scheme != nil {
+		_, k, err := scheme.DeriveKeyPair(seed)
+		if err != nil {
+			return nil, err
+		}
+		return k.MarshalBinary()
+	}
+	if scheme := OIDSigScheme(oid); scheme != nil {
+		_, k, err := scheme.DeriveKeyPair(seed)
+		if err != nil {
+			return nil, err
+		}
+		return k.MarshalBinary()
+	}
+	return nil, ErrUnknownOID
+}
+
+// ParsePKCS8PrivateKey decodes DER PKCS#8 into a crypto.KemPrivateKey or crypto.SigPrivateKey, the seed is returned
+// when present, both the seed and expanded forms must agree
+func ParsePKCS8PrivateKey(der []byte) (key any, seed []byte, err error) {
+	var p8 pkcs8
+	rest, err := asn1.Unmarshal(der, &p8)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 || (p8.Version != 0 && p8.Version != 1) || len(p8.Algorithm.Parameters.FullBytes) != 0 {
+		return nil, nil, ErrInvalidPKIX
+	}
+	var choice asn1.RawValue
+	rest, err = asn1.Unmarshal(p8.PrivateKey, &choice)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 {
+		return nil, nil, ErrInvalidPKIX
+	}
+	var expanded []byte
+	switch {
+	case choice.Class == asn1.ClassContextSpecific && choice.Tag == 0 && !choice.IsCompound:
+		seed = choice.Bytes
+	case choice.Class == asn1.ClassUniversal && choice.Tag == asn1.TagOctetString && !choice.IsCompound:
+		expanded = choice.Bytes
+	case choice.Class == asn1.ClassUniversal && choice.Tag == asn1.TagSequence:
+		var both pkcs8Both
+		if _, err = asn1.Unmarshal(choice.FullBytes, &both); err != nil {
+			return nil, nil, err
+		}
+		seed, expanded = both.Seed, both.ExpandedKey
+	default:
+		return nil, nil, ErrInvalidPKIX
+	}
+	if seed != nil {
+		derived, err := deriveFromSeed(p8.Algorithm.Algorithm, seed)
+		if err != nil {
+			return nil, nil, err
+		}
+		if expanded != nil && !bytes.Equal(derived, expanded) {
+			return nil, nil, ErrSeedMismatch
+		}
+		expanded = derived
+	}
+	if scheme := OIDKemScheme(p8.Algorithm.Algorithm); scheme != nil {
+		key, err = scheme.UnmarshalBinaryPrivateKey(expanded)
+		return key, seed, err
+	}
+	if scheme := OIDSigScheme(p8.Algorithm.Algorithm); scheme != nil {
+		key, err = scheme.UnmarshalBinaryPrivateKey(expanded)
+		return key, seed, err
+	}
+	return nil, nil, ErrUnknownOID
+}
+
+// MarshalPEMPublicKey encodes a public key as a PEM "PUBLIC KEY" block
+func MarshalPEMPublicKey(key encoding.BinaryMarshaler) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMPublicKeyType, Bytes: der}), nil
+}
+
+// MarshalPEMPrivateKey encodes a private key as a PEM "PRIVATE KEY" block, see MarshalPKCS8PrivateKey
+func MarshalPEMPrivateKey(key encoding.BinaryMarshaler, seed []byte, form PrivateKeyForm) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(key, seed, form)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMPrivateKeyType, Bytes: der}), nil
+}
+
+// ParsePEMPublicKey decodes the first PEM block which must be a "PUBLIC KEY", see ParsePKIXPublicKey
+func ParsePEMPublicKey(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PEMPublicKeyType {
+		return nil, ErrInvalidPEM
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+// ParsePEMPrivateKey decodes the first PEM block which must be a "PRIVATE KEY", see ParsePKCS8PrivateKey
+func ParsePEMPrivateKey(data []byte) (any, []byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PEMPrivateKeyType {
+		return nil, nil, ErrInvalidPEM
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
@@ -0,0 +1,120 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// seed form prefixes from the LAMPS drafts, followed by the raw seed
+const (
+	mldsa44SeedPrefix  = "3034020100300b0609608648016503040311042280" + "20"
+	mlkem768SeedPrefix = "3054020100300b0609608648016503040402044280" + "40"
+)
+
+func testSeed(size int) []byte {
+	seed := make([]byte, size)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	return seed
+}
+
+func TestPKIXKem(t *testing.T) {
+	for _, name := range []string{"ML-KEM-512", "ML-KEM-768", "ML-KEM-1024"} {
+		scheme := KemSchemeByName(name)
+		t.Run(name, func(t *testing.T) {
+			seed := testSeed(scheme.SeedSize())
+			pk, k, err := scheme.DeriveKeyPair(seed)
+			assert.NoError(t, err)
+			testPKIXRoundTrip(t, pk, k, seed, func(a, b any) bool {
+				if ak, ok := a.(crypto.KemPublicKey); ok {
+					return ak.Equals(b.(crypto.KemPublicKey))
+				}
+				return a.(crypto.KemPrivateKey).Equals(b.(crypto.KemPrivateKey))
+			})
+		})
+	}
+}
+
+func TestPKIXSig(t *testing.T) {
+	for _, name := range []string{"ML-DSA-44", "ML-DSA-65", "ML-DSA-87"} {
+		scheme := SigSchemeByName(name)
+		t.Run(name, func(t *testing.T) {
+			seed := testSeed(scheme.SeedSize())
+			pk, k, err := scheme.DeriveKeyPair(seed)
+			assert.NoError(t, err)
+			testPKIXRoundTrip(t, pk, k, seed, func(a, b any) bool {
+				if ak, ok := a.(crypto.SigPublicKey); ok {
+					return ak.Equals(b.(crypto.SigPublicKey))
+				}
+				return a.(crypto.SigPrivateKey).Equals(b.(crypto.SigPrivateKey))
+			})
+		})
+	}
+}
+
+func testPKIXRoundTrip(t *testing.T, pk, k encoding.BinaryMarshaler, seed []byte, equal func(a, b any) bool) {
+	pemPk, err := MarshalPEMPublicKey(pk)
+	assert.NoError(t, err)
+	rpk, err := ParsePEMPublicKey(pemPk)
+	assert.NoError(t, err)
+	assert.True(t, equal(pk, rpk))
+	_, err = MarshalPKIXPublicKey(k)
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+	_, err = MarshalPKCS8PrivateKey(pk, nil, PrivateKeyExpanded)
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+	for _, form := range []PrivateKeyForm{PrivateKeyExpanded, PrivateKeySeed, PrivateKeyBoth} {
+		pemK, err := MarshalPEMPrivateKey(k, seed, form)
+		assert.NoError(t, err)
+		rk, rseed, err := ParsePEMPrivateKey(pemK)
+		assert.NoError(t, err)
+		assert.True(t, equal(k, rk))
+		if form == PrivateKeyExpanded {
+			assert.Nil(t, rseed)
+		} else {
+			assert.Equal(t, seed, rseed)
+		}
+	}
+	_, err = MarshalPKCS8PrivateKey(k, nil, PrivateKeySeed)
+	assert.ErrorIs(t, err, ErrSeedRequired)
+	wrongSeed := bytes.Clone(seed)
+	wrongSeed[0] ^= 0xff
+	_, err = MarshalPKCS8PrivateKey(k, wrongSeed, PrivateKeyBoth)
+	assert.ErrorIs(t, err, ErrSeedMismatch)
+	_, _, err = ParsePEMPrivateKey(pemPk)
+	assert.ErrorIs(t, err, ErrInvalidPEM)
+}
+
+func TestPKCS8SeedEncoding(t *testing.T) {
+	_, k, err := WrapSig(mldsa44.Scheme()).DeriveKeyPair(testSeed(32))
+	assert.NoError(t, err)
+	der, err := MarshalPKCS8PrivateKey(k, testSeed(32), PrivateKeySeed)
+	assert.NoError(t, err)
+	assert.Equal(t, mldsa44SeedPrefix+hex.EncodeToString(testSeed(32)), hex.EncodeToString(der))
+
+	_, kk, err := WrapKem(mlkem768.Scheme()).DeriveKeyPair(testSeed(64))
+	assert.NoError(t, err)
+	der, err = MarshalPKCS8PrivateKey(kk, testSeed(64), PrivateKeySeed)
+	assert.NoError(t, err)
+	assert.Equal(t, mlkem768SeedPrefix+hex.EncodeToString(testSeed(64)), hex.EncodeToString(der))
+}
+
+func TestPKIXUnknownScheme(t *testing.T) {
+	pk, k, err := WrapKem(kyber768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = MarshalPKIXPublicKey(pk)
+	assert.ErrorIs(t, err, ErrUnknownOID)
+	_, err = MarshalPKCS8PrivateKey(k, nil, PrivateKeyExpanded)
+	assert.ErrorIs(t, err, ErrUnknownOID)
+	_, err = ParsePKIXPublicKey([]byte{0x30, 0x00})
+	assert.Error(t, err)
+}
@@ -0,0 +1,346 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/sign"
+	"hash"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Security levels are NIST PQC categories: 1 and 3 and 5 match AES-128, 192 and 256 key search, 2 and 4 match SHA-256
+// and SHA-384 collision search. Classical-only and unknown schemes have level 0.
+
+// Classification describes the kind of hardness a scheme relies on, values can be combined as a set in Policy
+type Classification uint8
+
+const (
+	PostQuantum Classification = 1 << iota
+	Classical
+	Hybrid
+)
+
+func (c Classification) String() string {
+	var parts []string
+	if c&PostQuantum != 0 {
+		parts = append(parts, "PQ")
+	}
+	if c&Classical != 0 {
+		parts = append(parts, "classical")
+	}
+	if c&Hybrid != 0 {
+		parts = append(parts, "hybrid")
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " | ")
+}
+
+// SchemeInfo is the security metadata of a scheme
+type SchemeInfo struct {
+	Level          int
+	FIPSApproved   bool
+	Classification Classification
+}
+
+// schemeInfos is keyed by lower case scheme name, including the circl hybrid and classical schemes this package does
+// not wrap itself so they are classified if passed to WrapKem / WrapSig
+var schemeInfos = map[string]SchemeInfo{
+	"ml-kem-512":          {1, true, PostQuantum},
+	"ml-kem-768":          {3, true, PostQuantum},
+	"ml-kem-1024":         {5, true, PostQuantum},
+	"kyber512":            {1, false, PostQuantum},
+	"kyber768":            {3, false, PostQuantum},
+	"kyber1024":           {5, false, PostQuantum},
+	"frodokem-640-shake":  {1, false, PostQuantum},
+	"kyber512-x25519":     {1, false, Hybrid},
+	"kyber768-x25519":     {3, false, Hybrid},
+	"kyber768-x448":       {3, false, Hybrid},
+	"kyber1024-x448":      {5, false, Hybrid},
+	"p256kyber768draft00": {3, false, Hybrid},
+	"x25519mlkem768":      {3, false, Hybrid},
+	"x-wing":              {3, false, Hybrid},
+	"ml-dsa-44":           {2, true, PostQuantum},
+	"ml-dsa-65":           {3, true, PostQuantum},
+	"ml-dsa-87":           {5, true, PostQuantum},
+	"dilithium2":          {2, false, PostQuantum},
+	"dilithium3":          {3, false, PostQuantum},
+	"dilithium5":          {5, false, PostQuantum},
+	"ed25519-dilithium2":  {2, false, Hybrid},
+	"ed448-dilithium3":    {3, false, Hybrid},
+	"ed25519":             {0, true, Classical},
+	"ed448":               {0, true, Classical},
+}
+
+// customSchemeInfos holds RegisterSchemeInfo entries, which take priority over schemeInfos
+var customSchemeInfos = &sync.Map{}
+
+// hashInfos are by collision resistance, SHA-224 and below do not reach category 1
+var hashInfos = map[stdcrypto.Hash]SchemeInfo{
+	stdcrypto.SHA224:     {0, true, Classical},
+	stdcrypto.SHA256:     {2, true, Classical},
+	stdcrypto.SHA384:     {4, true, Classical},
+	stdcrypto.SHA512:     {5, true, Classical},
+	stdcrypto.SHA512_224: {0, true, Classical},
+	stdcrypto.SHA512_256: {2, true, Classical},
+	stdcrypto.SHA3_224:   {0, true, Classical},
+	stdcrypto.SHA3_256:   {2, true, Classical},
+	stdcrypto.SHA3_384:   {4, true, Classical},
+	stdcrypto.SHA3_512:   {5, true, Classical},
+}
+
+// hashIDs are the crypto.Hash values of each standard library hash implementation, which is shared between digest
+// sizes
+var hashIDs = map[reflect.Type][]stdcrypto.Hash{
+	reflect.TypeOf(sha1.New()):    {stdcrypto.SHA1},
+	reflect.TypeOf(sha256.New()):  {stdcrypto.SHA224, stdcrypto.SHA256},
+	reflect.TypeOf(sha512.New()):  {stdcrypto.SHA384, stdcrypto.SHA512, stdcrypto.SHA512_224, stdcrypto.SHA512_256},
+	reflect.TypeOf(sha3.New256()): {stdcrypto.SHA3_224, stdcrypto.SHA3_256, stdcrypto.SHA3_384, stdcrypto.SHA3_512},
+}
+
+// HashOf returns the crypto.Hash h implements, told apart from others sharing its type by its size, 0 is returned for
+// nil and unknown hashes
+func HashOf(h hash.Hash) stdcrypto.Hash {
+	if h == nil {
+		return 0
+	}
+	for _, id := range hashIDs[reflect.TypeOf(h)] {
+		if id.Size() == h.Size() {
+			return id
+		}
+	}
+	return 0
+}
+
+// RegisterSchemeInfo sets the security metadata for a scheme name, for schemes not known to this package
+func RegisterSchemeInfo(name string, info SchemeInfo) {
+	customSchemeInfos.Store(strings.ToLower(name), info)
+}
+
+// SchemeInfoByName returns the security metadata of a scheme, false is returned for unknown schemes
+func SchemeInfoByName(name string) (SchemeInfo, bool) {
+	name = strings.ToLower(name)
+	if info, ok := customSchemeInfos.Load(name); ok {
+		return info.(SchemeInfo), true
+	}
+	info, ok := schemeInfos[name]
+	return info, ok
+}
+
+// HashInfo returns the security metadata of a hash, the hash is used for the signed digest so collision resistance
+// decides the level, false is returned for hashes not known to be secure
+func HashInfo(h stdcrypto.Hash) (SchemeInfo, bool) {
+	info, ok := hashInfos[h]
+	return info, ok
+}
+
+func (k KemWrapper) Info() SchemeInfo {
+	info, _ := SchemeInfoByName(k.Name())
+	return info
+}
+
+// SecurityLevel is the NIST category of the scheme, 0 if it is unknown or classical
+func (k KemWrapper) SecurityLevel() int {
+	return k.Info().Level
+}
+
+func (k KemWrapper) FIPSApproved() bool {
+	return k.Info().FIPSApproved
+}
+
+func (k KemWrapper) Classification() Classification {
+	return k.Info().Classification
+}
+
+func (s SigWrapper) Info() SchemeInfo {
+	info, _ := SchemeInfoByName(s.Name())
+	return info
+}
+
+// SecurityLevel is the NIST category of the scheme, 0 if it is unknown or classical
+func (s SigWrapper) SecurityLevel() int {
+	return s.Info().Level
+}
+
+func (s SigWrapper) FIPSApproved() bool {
+	return s.Info().FIPSApproved
+}
+
+func (s SigWrapper) Classification() Classification {
+	return s.Info().Classification
+}
+
+var ErrSecurityLevel = errors.New("security level below policy minimum")
+var ErrLevelMismatch = errors.New("KEM and signature security levels too far apart")
+var ErrNotFIPSApproved = errors.New("not FIPS approved")
+var ErrClassification = errors.New("scheme classification not allowed by policy")
+
+// Policy is the minimum requirements for a KEM, signature scheme and signature hash used together
+type Policy struct {
+	// MinLevel is the lowest NIST category accepted for each of the KEM, signature scheme and hash
+	MinLevel int
+	// MaxLevelGap is the largest difference between the KEM and signature scheme categories, 0 for no limit
+	MaxLevelGap int
+	// RequireFIPS rejects anything not FIPS approved
+	RequireFIPS bool
+	// Allowed is the set of classifications accepted for the KEM and signature scheme, PostQuantum | Hybrid if 0
+	Allowed Classification
+}
+
+// DefaultPolicy accepts any post quantum or hybrid pairing at least category 1 with categories no more than 2 apart, so
+// ML-KEM-768 with ML-DSA-44 is fine but ML-KEM-1024 with ML-DSA-44 is not
+var DefaultPolicy = Policy{MinLevel: 1, MaxLevelGap: 2}
+
+// FIPSPolicy is DefaultPolicy limited to FIPS approved schemes and hashes
+var FIPSPolicy = Policy{MinLevel: 1, MaxLevelGap: 2, RequireFIPS: true}
+
+// CNSA2Policy requires category 5 FIPS approved pure post quantum schemes, ML-KEM-1024 and ML-DSA-87 with SHA-512
+var CNSA2Policy = Policy{MinLevel: 5, RequireFIPS: true, Allowed: PostQuantum}
+
+// NoPolicy accepts anything
+var NoPolicy = Policy{Allowed: PostQuantum | Classical | Hybrid}
+
+var policies = map[string]*Policy{
+	"default": &DefaultPolicy,
+	"fips":    &FIPSPolicy,
+	"cnsa2":   &CNSA2Policy,
+	"none":    &NoPolicy,
+}
+
+// PolicyByName returns one of the policies "default", "fips", "cnsa2" or "none"
+func PolicyByName(name string) (Policy, bool) {
+	p, ok := policies[strings.ToLower(name)]
+	if !ok {
+		return Policy{}, false
+	}
+	return *p, true
+}
+
+func (p Policy) allowed() Classification {
+	if p.Allowed == 0 {
+		return PostQuantum | Hybrid
+	}
+	return p.Allowed
+}
+
+func (p Policy) checkInfo(name string, info SchemeInfo, known bool, classify bool) error {
+	if !known {
+		info = SchemeInfo{}
+	}
+	if classify && info.Classification&p.allowed() == 0 {
+		return fmt.Errorf("%w: %s is %s", ErrClassification, name, info.Classification)
+	}
+	if info.Level < p.MinLevel {
+		return fmt.Errorf("%w: %s is level %d, %d required", ErrSecurityLevel, name, info.Level, p.MinLevel)
+	}
+	if p.RequireFIPS && !info.FIPSApproved {
+		return fmt.Errorf("%w: %s", ErrNotFIPSApproved, name)
+	}
+	return nil
+}
+
+// CheckKem checks a KEM on its own
+func (p Policy) CheckKem(scheme crypto.KemScheme) error {
+	if nilScheme(scheme) {
+		return ErrUnknownScheme
+	}
+	info, ok := SchemeInfoByName(scheme.Name())
+	return p.checkInfo(scheme.Name(), info, ok, true)
+}
+
+// CheckSig checks a signature scheme on its own
+func (p Policy) CheckSig(scheme crypto.SigScheme) error {
+	if nilScheme(scheme) {
+		return ErrUnknownScheme
+	}
+	info, ok := SchemeInfoByName(scheme.Name())
+	return p.checkInfo(scheme.Name(), info, ok, true)
+}
+
+// nilScheme is true for a nil scheme, including a nil *KemWrapper or *SigWrapper
+func nilScheme(scheme any) bool {
+	if scheme == nil {
+		return true
+	}
+	v := reflect.ValueOf(scheme)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// CheckHash checks a signature hash on its own
+func (p Policy) CheckHash(h stdcrypto.Hash) error {
+	info, ok := HashInfo(h)
+	return p.checkInfo(h.String(), info, ok, false)
+}
+
+// Check validates a KEM, signature scheme and signature hash used together
+func (p Policy) Check(kemScheme crypto.KemScheme, sigScheme crypto.SigScheme, h stdcrypto.Hash) error {
+	if err := p.CheckHash(h); err != nil {
+		return err
+	}
+	return p.checkPair(kemScheme, sigScheme)
+}
+
+// CheckSigned is Check for SigData signed with h, see HashOf, a nil h signs the full data so is not checked
+func (p Policy) CheckSigned(kemScheme crypto.KemScheme, sigScheme crypto.SigScheme, h hash.Hash) error {
+	if h != nil {
+		if err := p.CheckHash(HashOf(h)); err != nil {
+			return err
+		}
+	}
+	return p.checkPair(kemScheme, sigScheme)
+}
+
+// checkPair checks the KEM and signature scheme on their own and their level gap
+func (p Policy) checkPair(kemScheme crypto.KemScheme, sigScheme crypto.SigScheme) error {
+	if err := p.CheckKem(kemScheme); err != nil {
+		return err
+	}
+	if err := p.CheckSig(sigScheme); err != nil {
+		return err
+	}
+	if p.MaxLevelGap == 0 {
+		return nil
+	}
+	kemInfo, _ := SchemeInfoByName(kemScheme.Name())
+	sigInfo, _ := SchemeInfoByName(sigScheme.Name())
+	if gap := kemInfo.Level - sigInfo.Level; max(gap, -gap) > p.MaxLevelGap {
+		return fmt.Errorf("%w: %s is level %d, %s is level %d", ErrLevelMismatch, kemScheme.Name(), kemInfo.Level, sigScheme.Name(), sigInfo.Level)
+	}
+	return nil
+}
+
+// WrapKem is TryWrapKem also refusing schemes the policy does not accept on their own
+func (p Policy) WrapKem(scheme kem.Scheme) (*KemWrapper, error) {
+	w, err := TryWrapKem(scheme)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckKem(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WrapSig is TryWrapSig also refusing schemes the policy does not accept on their own
+func (p Policy) WrapSig(scheme sign.Scheme) (*SigWrapper, error) {
+	w, err := TryWrapSig(scheme)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckSig(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
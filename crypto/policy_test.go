@@ -0,0 +1,149 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"github.com/stretchr/testify/assert"
+	"hash"
+	"hash/fnv"
+	"testing"
+)
+
+func TestSchemeInfo(t *testing.T) {
+	for _, s := range knownKemSchemes {
+		scheme := WrapKem(s)
+		assert.Positive(t, scheme.SecurityLevel(), scheme.Name())
+		assert.Equal(t, PostQuantum, scheme.Classification(), scheme.Name())
+	}
+	for _, s := range knownSigSchemes {
+		scheme := WrapSig(s)
+		assert.Positive(t, scheme.SecurityLevel(), scheme.Name())
+		assert.Equal(t, PostQuantum, scheme.Classification(), scheme.Name())
+	}
+	assert.Equal(t, 3, WrapKem(mlkem768.Scheme()).SecurityLevel())
+	assert.True(t, WrapKem(mlkem768.Scheme()).FIPSApproved())
+	assert.False(t, WrapKem(kyber768.Scheme()).FIPSApproved())
+	assert.Equal(t, 2, WrapSig(mldsa44.Scheme()).SecurityLevel())
+	assert.Equal(t, 5, WrapSig(mldsa87.Scheme()).SecurityLevel())
+
+	info, ok := SchemeInfoByName("X-Wing")
+	assert.True(t, ok)
+	assert.Equal(t, Hybrid, info.Classification)
+	_, ok = SchemeInfoByName("Test-Scheme")
+	assert.False(t, ok)
+	RegisterSchemeInfo("Test-Scheme", SchemeInfo{Level: 1, Classification: Classical})
+	info, ok = SchemeInfoByName("test-scheme")
+	assert.True(t, ok)
+	assert.Equal(t, Classical, info.Classification)
+
+	assert.Equal(t, "PQ", PostQuantum.String())
+	assert.Equal(t, "PQ | hybrid", (PostQuantum | Hybrid).String())
+	assert.Equal(t, "unknown", Classification(0).String())
+}
+
+func TestPolicy(t *testing.T) {
+	kem512 := WrapKem(mlkem512.Scheme())
+	kem768 := WrapKem(mlkem768.Scheme())
+	kem1024 := WrapKem(mlkem1024.Scheme())
+	kyber := WrapKem(kyber768.Scheme())
+	sig44 := WrapSig(mldsa44.Scheme())
+	sig65 := WrapSig(mldsa65.Scheme())
+	sig87 := WrapSig(mldsa87.Scheme())
+
+	assert.NoError(t, DefaultPolicy.Check(kem768, sig44, stdcrypto.SHA256))
+	assert.NoError(t, DefaultPolicy.Check(kem512, sig44, stdcrypto.SHA256))
+	assert.NoError(t, DefaultPolicy.Check(kyber, sig65, stdcrypto.SHA3_384))
+	assert.ErrorIs(t, DefaultPolicy.Check(kem1024, sig44, stdcrypto.SHA512), ErrLevelMismatch)
+	assert.ErrorIs(t, DefaultPolicy.Check(kem768, sig44, stdcrypto.SHA1), ErrSecurityLevel)
+	assert.ErrorIs(t, DefaultPolicy.Check(kem768, sig44, stdcrypto.SHA224), ErrSecurityLevel)
+	assert.ErrorIs(t, DefaultPolicy.Check(nil, sig44, stdcrypto.SHA256), ErrUnknownScheme)
+	assert.ErrorIs(t, DefaultPolicy.Check((*KemWrapper)(nil), sig44, stdcrypto.SHA256), ErrUnknownScheme)
+	assert.ErrorIs(t, DefaultPolicy.CheckSig((*SigWrapper)(nil)), ErrUnknownScheme)
+	assert.NoError(t, DefaultPolicy.Check(kem768, sig44, stdcrypto.SHA512_256))
+	assert.ErrorIs(t, DefaultPolicy.Check(kem768, sig44, stdcrypto.SHA512_224), ErrSecurityLevel)
+
+	assert.ErrorIs(t, FIPSPolicy.Check(kyber, sig65, stdcrypto.SHA384), ErrNotFIPSApproved)
+	assert.NoError(t, FIPSPolicy.Check(kem768, sig65, stdcrypto.SHA384))
+
+	assert.NoError(t, CNSA2Policy.Check(kem1024, sig87, stdcrypto.SHA512))
+	assert.ErrorIs(t, CNSA2Policy.Check(kem1024, sig87, stdcrypto.SHA384), ErrSecurityLevel)
+	assert.ErrorIs(t, CNSA2Policy.CheckKem(kem768), ErrSecurityLevel)
+
+	RegisterSchemeInfo("Test-Classical", SchemeInfo{Level: 0, FIPSApproved: true, Classification: Classical})
+	classical := testSigScheme{name: "Test-Classical"}
+	assert.ErrorIs(t, DefaultPolicy.CheckSig(classical), ErrClassification)
+	assert.NoError(t, NoPolicy.CheckSig(classical))
+	assert.ErrorIs(t, DefaultPolicy.CheckSig(testSigScheme{name: "Unknown-Scheme"}), ErrClassification)
+	assert.NoError(t, NoPolicy.Check(kem1024, sig44, stdcrypto.MD5))
+
+	assert.NoError(t, DefaultPolicy.CheckSigned(kem768, sig44, sha256.New()))
+	assert.NoError(t, DefaultPolicy.CheckSigned(kem768, sig44, nil))
+	assert.ErrorIs(t, DefaultPolicy.CheckSigned(kem768, sig44, sha256.New224()), ErrSecurityLevel)
+	assert.ErrorIs(t, DefaultPolicy.CheckSigned(kem1024, sig44, nil), ErrLevelMismatch)
+	assert.ErrorIs(t, CNSA2Policy.CheckSigned(kem1024, sig87, sha512.New384()), ErrSecurityLevel)
+
+	for _, name := range []string{"default", "FIPS", "cnsa2", "none"} {
+		_, ok := PolicyByName(name)
+		assert.True(t, ok, name)
+	}
+	_, ok := PolicyByName("bogus")
+	assert.False(t, ok)
+}
+
+func TestHashOf(t *testing.T) {
+	for want, h := range map[stdcrypto.Hash]hash.Hash{
+		stdcrypto.SHA1:       sha1.New(),
+		stdcrypto.SHA224:     sha256.New224(),
+		stdcrypto.SHA256:     sha256.New(),
+		stdcrypto.SHA384:     sha512.New384(),
+		stdcrypto.SHA512:     sha512.New(),
+		stdcrypto.SHA512_224: sha512.New512_224(),
+		stdcrypto.SHA512_256: sha512.New512_256(),
+		stdcrypto.SHA3_224:   sha3.New224(),
+		stdcrypto.SHA3_256:   sha3.New256(),
+		stdcrypto.SHA3_384:   sha3.New384(),
+		stdcrypto.SHA3_512:   sha3.New512(),
+	} {
+		assert.Equal(t, want, HashOf(h), want.String())
+	}
+	assert.Zero(t, HashOf(nil))
+	assert.Zero(t, HashOf(fnv.New64()))
+}
+
+func TestPolicyWrap(t *testing.T) {
+	setFIPSMode(t, false)
+	w, err := CNSA2Policy.WrapKem(mlkem1024.Scheme())
+	assert.NoError(t, err)
+	assert.Same(t, WrapKem(mlkem1024.Scheme()), w)
+	_, err = CNSA2Policy.WrapKem(mlkem768.Scheme())
+	assert.ErrorIs(t, err, ErrSecurityLevel)
+	_, err = FIPSPolicy.WrapKem(kyber768.Scheme())
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	_, err = CNSA2Policy.WrapSig(mldsa87.Scheme())
+	assert.NoError(t, err)
+	_, err = CNSA2Policy.WrapSig(mldsa65.Scheme())
+	assert.ErrorIs(t, err, ErrSecurityLevel)
+}
+
+// testSigScheme is a signature scheme with only a name
+type testSigScheme struct {
+	crypto.SigScheme
+	name string
+}
+
+func (s testSigScheme) Name() string {
+	return s.name
+}
@@ -0,0 +1,242 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package prekey
+
+import (
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/1f349/pqc-handshake/crypto/trust"
+	"hash"
+	"sync"
+	"time"
+)
+
+// Prekeys are ephemeral KEM keys signed ahead of time so answering a public key request needs no key generation or
+// signing
+
+var ErrNoKeys = errors.New("no valid prekeys")
+
+// DefaultInventorySize is the number of keys kept when Config.Size is 0
+const DefaultInventorySize = 4
+
+// DefaultLifetime is the SigData validity when Config.Lifetime is 0
+const DefaultLifetime = time.Hour
+
+// KeyGenerator creates KEM key pairs, crypto.KemScheme and pqc_crypto.KeyPool are both KeyGenerators
+type KeyGenerator interface {
+	GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error)
+}
+
+// Config for an Inventory
+type Config struct {
+	// Size is the number of signed keys kept, DefaultInventorySize if 0
+	Size int
+	// Lifetime is how long each SigData is valid, DefaultLifetime if 0
+	Lifetime time.Duration
+	// Renew is how long before ExpiryTime a key is renewed, a quarter of Lifetime if 0
+	Renew time.Duration
+	// Resign keeps the KEM key when renewing and only signs it again, otherwise a new key is generated
+	Resign bool
+	// NewHash creates the hash the KEM keys are signed with, nil signs the full key
+	NewHash func() hash.Hash
+	// Now is the clock, time.Now if nil
+	Now func() time.Time
+	// Policy the KEM keys, signing key and hash are checked against, pqc_crypto.DefaultPolicy if nil
+	Policy *pqc_crypto.Policy
+}
+
+// SignedKey is a KEM key pair with its signature
+type SignedKey struct {
+	Public  crypto.KemPublicKey
+	Private crypto.KemPrivateKey
+	SigData *crypto.SigData
+	// Payload is the signed key ready to send, its SigPubKeyHash is the SHA-256 fingerprint of the signing key
+	Payload *packets.PublicKeySignedPacketPayload
+}
+
+// Inventory keeps Size signed KEM keys, renewing them before they expire, it is safe for concurrent use
+type Inventory struct {
+	keys        KeyGenerator
+	signer      crypto.SigPrivateKey
+	fingerprint []byte
+	config      Config
+	// renewMu is held for a whole renewal, which generates and signs keys without holding mu
+	renewMu  sync.Mutex
+	mu       sync.Mutex
+	signed   []*SignedKey
+	next     int
+	renewing bool
+}
+
+// NewInventory signs keys from keys with signer, the inventory is filled before returning
+func NewInventory(keys KeyGenerator, signer crypto.SigPrivateKey, config Config) (*Inventory, error) {
+	if keys == nil || signer == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if config.Size <= 0 {
+		config.Size = DefaultInventorySize
+	}
+	if config.Lifetime <= 0 {
+		config.Lifetime = DefaultLifetime
+	}
+	if config.Renew <= 0 {
+		config.Renew = config.Lifetime / 4
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	if config.Policy == nil {
+		config.Policy = &pqc_crypto.DefaultPolicy
+	}
+	if err := config.Policy.CheckSig(signer.Scheme()); err != nil {
+		return nil, err
+	}
+	fp, err := trust.Fingerprint(signer.Public(), nil)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Inventory{keys: keys, signer: signer, fingerprint: fp, config: config, signed: make([]*SignedKey, config.Size)}
+	if err := inv.Renew(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// sign creates the SigData and payload for a key pair
+func (inv *Inventory) sign(pk crypto.KemPublicKey, sk crypto.KemPrivateKey, now time.Time) (*SignedKey, error) {
+	bts, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var h hash.Hash
+	if inv.config.NewHash != nil {
+		h = inv.config.NewHash()
+	}
+	if err := inv.config.Policy.CheckSigned(pk.Scheme(), inv.signer.Scheme(), h); err != nil {
+		return nil, err
+	}
+	sigData, err := pqc_crypto.NewSigData(bts, now, now.Add(inv.config.Lifetime), h, inv.signer)
+	if err != nil {
+		return nil, err
+	}
+	if sigData == nil {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	payload := &packets.PublicKeySignedPacketPayload{SigPubKeyHash: inv.fingerprint}
+	if err := payload.Save(sigData); err != nil {
+		return nil, err
+	}
+	return &SignedKey{Public: pk, Private: sk, SigData: sigData, Payload: payload}, nil
+}
+
+// due returns the indexes of every missing key or key within Renew of its expiry, inv.mu must be held
+func (inv *Inventory) due(now time.Time) []int {
+	var due []int
+	for i, k := range inv.signed {
+		if k == nil || !now.Before(k.SigData.ExpiryTime.Add(-inv.config.Renew)) {
+			due = append(due, i)
+		}
+	}
+	return due
+}
+
+// renewKey signs a replacement for k, which is nil for a missing key
+func (inv *Inventory) renewKey(k *SignedKey, now time.Time) (*SignedKey, error) {
+	if k != nil && inv.config.Resign {
+		return inv.sign(k.Public, k.Private, now)
+	}
+	pk, sk, err := inv.keys.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	return inv.sign(pk, sk, now)
+}
+
+// Renew replaces the keys close to expiry now, returning the first error. Keys are generated and signed without
+// blocking Current, which also starts Renew in the background once a key is due
+func (inv *Inventory) Renew() error {
+	inv.renewMu.Lock()
+	defer inv.renewMu.Unlock()
+	now := inv.config.Now()
+	inv.mu.Lock()
+	due := inv.due(now)
+	old := make([]*SignedKey, len(due))
+	for j, i := range due {
+		old[j] = inv.signed[i]
+	}
+	inv.mu.Unlock()
+
+	var firstErr error
+	for j, i := range due {
+		k, err := inv.renewKey(old[j], now)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		inv.mu.Lock()
+		inv.signed[i] = k
+		inv.mu.Unlock()
+	}
+	return firstErr
+}
+
+// valid returns the next valid key in turn, inv.mu must be held
+func (inv *Inventory) valid(now time.Time) *SignedKey {
+	for range inv.signed {
+		k := inv.signed[inv.next]
+		inv.next = (inv.next + 1) % len(inv.signed)
+		if k != nil && !now.Before(k.SigData.IssueTime) && !now.After(k.SigData.ExpiryTime) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Current returns the next valid key in turn, starting Renew in the background if any key is due. Only when no valid
+// key is left does it wait for Renew, returning its error if there is still no valid key
+func (inv *Inventory) Current() (*SignedKey, error) {
+	inv.mu.Lock()
+	now := inv.config.Now()
+	k := inv.valid(now)
+	if k != nil && !inv.renewing && len(inv.due(now)) > 0 {
+		inv.renewing = true
+		go func() {
+			_ = inv.Renew()
+			inv.mu.Lock()
+			inv.renewing = false
+			inv.mu.Unlock()
+		}()
+	}
+	inv.mu.Unlock()
+	if k != nil {
+		return k, nil
+	}
+
+	err := inv.Renew()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if k := inv.valid(inv.config.Now()); k != nil {
+		return k, nil
+	}
+	if err == nil {
+		err = ErrNoKeys
+	}
+	return nil, err
+}
+
+// Keys returns the signed keys currently held
+func (inv *Inventory) Keys() []*SignedKey {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	keys := make([]*SignedKey, 0, len(inv.signed))
+	for _, k := range inv.signed {
+		if k != nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
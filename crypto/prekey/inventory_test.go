@@ -0,0 +1,213 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package prekey
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/1f349/pqc-handshake/crypto/trust"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClock is safe to read from background renewals
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{now: now}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestInventory(t *testing.T, keys KeyGenerator, config Config) (*Inventory, *trust.MemoryStore) {
+	pk, k, err := pqc_crypto.WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	store := trust.NewMemoryStore(nil)
+	_, err = store.Add(pk)
+	assert.NoError(t, err)
+	inv, err := NewInventory(keys, k, config)
+	assert.NoError(t, err)
+	return inv, store
+}
+
+func TestInventory(t *testing.T) {
+	// SigData.Verify uses the real time so the fake clock starts in the past and is moved up to now
+	clock := newTestClock(time.Now().Add(-55 * time.Minute))
+	inv, store := newTestInventory(t, pqc_crypto.WrapKem(mlkem768.Scheme()), Config{
+		Size:     2,
+		Lifetime: time.Hour,
+		Renew:    10 * time.Minute,
+		NewHash:  sha256.New,
+		Now:      clock.Now,
+	})
+	first := inv.Keys()
+	assert.Len(t, first, 2)
+
+	// keys are served in turn and verify as signed packets
+	a, err := inv.Current()
+	assert.NoError(t, err)
+	b, err := inv.Current()
+	assert.NoError(t, err)
+	assert.NotSame(t, a, b)
+	c, err := inv.Current()
+	assert.NoError(t, err)
+	assert.Same(t, a, c)
+	key, err := trust.VerifySignedPacket(store, a.Payload, a.Public, sha256.New())
+	assert.NoError(t, err)
+	assert.NotNil(t, key)
+
+	// nothing changes until the renewal window
+	clock.Add(40 * time.Minute)
+	_, err = inv.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, first, inv.Keys())
+
+	// a still valid key is served while the renewal runs in the background, Renew waits for it
+	clock.Add(12 * time.Minute)
+	d, err := inv.Current()
+	assert.NoError(t, err)
+	assert.Contains(t, first, d)
+	assert.NoError(t, inv.Renew())
+	for _, k := range inv.Keys() {
+		assert.NotContains(t, first, k)
+		assert.False(t, k.Public.Equals(first[0].Public))
+		assert.True(t, clock.Now().Add(time.Hour).Sub(k.SigData.ExpiryTime) < time.Millisecond)
+	}
+	e, err := inv.Current()
+	assert.NoError(t, err)
+	assert.NotContains(t, first, e)
+	_, err = trust.VerifySignedPacket(store, e.Payload, e.Public, sha256.New())
+	assert.NoError(t, err)
+}
+
+func TestInventoryResign(t *testing.T) {
+	clock := newTestClock(time.Now())
+	inv, _ := newTestInventory(t, pqc_crypto.WrapKem(mlkem768.Scheme()), Config{Size: 1, Resign: true, Now: clock.Now})
+	a, err := inv.Current()
+	assert.NoError(t, err)
+	// no key is valid so Current waits for the renewal
+	clock.Add(DefaultLifetime + time.Millisecond)
+	b, err := inv.Current()
+	assert.NoError(t, err)
+	assert.NotSame(t, a, b)
+	assert.True(t, a.Public.Equals(b.Public))
+	assert.True(t, b.SigData.ExpiryTime.After(a.SigData.ExpiryTime))
+}
+
+// failingKeys fails once enabled
+type failingKeys struct {
+	crypto.KemScheme
+	fail atomic.Bool
+}
+
+var errTestGenerate = errors.New("test generate failure")
+
+func (f *failingKeys) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	if f.fail.Load() {
+		return nil, nil, errTestGenerate
+	}
+	return f.KemScheme.GenerateKeyPair()
+}
+
+func TestInventoryRenewFailure(t *testing.T) {
+	clock := newTestClock(time.Now())
+	keys := &failingKeys{KemScheme: pqc_crypto.WrapKem(mlkem768.Scheme())}
+	inv, _ := newTestInventory(t, keys, Config{Size: 2, Lifetime: time.Hour, Now: clock.Now})
+	keys.fail.Store(true)
+
+	// still valid keys are served while renewal fails
+	clock.Add(50 * time.Minute)
+	assert.ErrorIs(t, inv.Renew(), errTestGenerate)
+	_, err := inv.Current()
+	assert.NoError(t, err)
+
+	clock.Add(time.Hour)
+	_, err = inv.Current()
+	assert.ErrorIs(t, err, errTestGenerate)
+
+	keys.fail.Store(false)
+	_, err = inv.Current()
+	assert.NoError(t, err)
+
+	_, err = NewInventory(keys, nil, Config{})
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
+
+// blockingKeys blocks key generation until release is closed
+type blockingKeys struct {
+	crypto.KemScheme
+	block   atomic.Bool
+	release chan struct{}
+}
+
+func (b *blockingKeys) GenerateKeyPair() (crypto.KemPublicKey, crypto.KemPrivateKey, error) {
+	if b.block.Load() {
+		<-b.release
+	}
+	return b.KemScheme.GenerateKeyPair()
+}
+
+func TestInventoryRenewInBackground(t *testing.T) {
+	clock := newTestClock(time.Now())
+	keys := &blockingKeys{KemScheme: pqc_crypto.WrapKem(mlkem768.Scheme()), release: make(chan struct{})}
+	inv, _ := newTestInventory(t, keys, Config{Size: 2, Lifetime: time.Hour, Now: clock.Now})
+	first := inv.Keys()
+	keys.block.Store(true)
+
+	// Current does not wait for the blocked renewal
+	clock.Add(50 * time.Minute)
+	for range 4 {
+		k, err := inv.Current()
+		assert.NoError(t, err)
+		assert.Contains(t, first, k)
+	}
+	close(keys.release)
+	assert.NoError(t, inv.Renew())
+	for _, k := range inv.Keys() {
+		assert.NotContains(t, first, k)
+	}
+}
+
+func TestInventoryKeyPool(t *testing.T) {
+	pool := pqc_crypto.NewKeyPool(pqc_crypto.WrapKem(mlkem768.Scheme()), pqc_crypto.KeyPoolConfig{Size: 2})
+	defer pool.Close()
+	inv, _ := newTestInventory(t, pool, Config{Size: 2})
+	assert.Len(t, inv.Keys(), 2)
+}
+
+func TestInventoryPolicy(t *testing.T) {
+	_, k, err := pqc_crypto.WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	kem1024 := pqc_crypto.WrapKem(mlkem1024.Scheme())
+	_, err = NewInventory(kem1024, k, Config{})
+	assert.ErrorIs(t, err, pqc_crypto.ErrLevelMismatch)
+	_, err = NewInventory(pqc_crypto.WrapKem(mlkem768.Scheme()), k, Config{NewHash: sha1.New})
+	assert.ErrorIs(t, err, pqc_crypto.ErrSecurityLevel)
+	_, err = NewInventory(kem1024, k, Config{Policy: &pqc_crypto.CNSA2Policy})
+	assert.ErrorIs(t, err, pqc_crypto.ErrSecurityLevel)
+	inv, err := NewInventory(kem1024, k, Config{Size: 1, Policy: &pqc_crypto.NoPolicy})
+	assert.NoError(t, err)
+	assert.Len(t, inv.Keys(), 1)
+}
@@ -0,0 +1,127 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"github.com/cloudflare/circl/kem/kyber/kyber512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/sign/dilithium/mode2"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const registryStressGoroutines = 4096
+
+func TestWrapKemConcurrent(t *testing.T) {
+	wrapped := make([]*KemWrapper, registryStressGoroutines)
+	wg := &sync.WaitGroup{}
+	start := make(chan struct{})
+	for i := range wrapped {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			wrapped[i] = WrapKem(knownKemSchemes[i%len(knownKemSchemes)])
+		}()
+	}
+	close(start)
+	wg.Wait()
+	for i, w := range wrapped {
+		assert.NotNil(t, w)
+		assert.Same(t, wrapped[i%len(knownKemSchemes)], w)
+		assert.Equal(t, knownKemSchemes[i%len(knownKemSchemes)].Name(), w.Name())
+	}
+}
+
+func TestWrapSigConcurrent(t *testing.T) {
+	wrapped := make([]*SigWrapper, registryStressGoroutines)
+	wg := &sync.WaitGroup{}
+	start := make(chan struct{})
+	for i := range wrapped {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			wrapped[i] = WrapSig(knownSigSchemes[i%len(knownSigSchemes)])
+		}()
+	}
+	close(start)
+	wg.Wait()
+	for i, w := range wrapped {
+		assert.NotNil(t, w)
+		assert.Same(t, wrapped[i%len(knownSigSchemes)], w)
+		assert.Equal(t, knownSigSchemes[i%len(knownSigSchemes)].Name(), w.Name())
+	}
+}
+
+func TestKemKeySchemeConcurrent(t *testing.T) {
+	// Keys created through raw circl, the scheme may never have been passed to WrapKem
+	pk, k, err := kyber512.Scheme().GenerateKeyPair()
+	assert.NoError(t, err)
+	wpk, wk := &KemPublicKeyWrapper{pk}, &KemPrivateKeyWrapper{k}
+	wg := &sync.WaitGroup{}
+	for range registryStressGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Same(t, wpk.Scheme(), wk.Scheme())
+		}()
+	}
+	wg.Wait()
+	assert.Same(t, WrapKem(kyber512.Scheme()), wpk.Scheme())
+}
+
+func TestSigKeySchemeConcurrent(t *testing.T) {
+	// Keys created through raw circl, the scheme may never have been passed to WrapSig
+	pk, k, err := mode2.Scheme().GenerateKey()
+	assert.NoError(t, err)
+	wpk, wk := &SigPublicKeyWrapper{pk}, &SigPrivateKeyWrapper{k}
+	wg := &sync.WaitGroup{}
+	for range registryStressGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Same(t, wpk.Scheme(), wk.Scheme())
+		}()
+	}
+	wg.Wait()
+	assert.Same(t, WrapSig(mode2.Scheme()), wpk.Scheme())
+}
+
+func TestKeySchemeNeverNil(t *testing.T) {
+	kpk, _, err := mlkem1024.Scheme().GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NotNil(t, KemPublicKeyWrapper{kpk}.Scheme())
+	spk, _, err := mldsa87.Scheme().GenerateKey()
+	assert.NoError(t, err)
+	assert.NotNil(t, SigPublicKeyWrapper{spk}.Scheme())
+}
+
+func BenchmarkWrapKem(b *testing.B) {
+	scheme := mlkem1024.Scheme()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = WrapKem(scheme)
+		}
+	})
+}
+
+func BenchmarkWrapSig(b *testing.B) {
+	scheme := mldsa87.Scheme()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = WrapSig(scheme)
+		}
+	})
+}
+
+func TestSchemeByName(t *testing.T) {
+	assert.Same(t, WrapKem(mlkem1024.Scheme()), KemSchemeByName("ml-kem-1024"))
+	assert.Same(t, WrapSig(mldsa87.Scheme()), SigSchemeByName("ML-DSA-87"))
+	assert.Nil(t, KemSchemeByName("ML-DSA-87"))
+	assert.Nil(t, SigSchemeByName("ML-KEM-1024"))
+}
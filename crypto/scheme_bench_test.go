@@ -0,0 +1,121 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	kem_schemes "github.com/cloudflare/circl/kem/schemes"
+	sign_schemes "github.com/cloudflare/circl/sign/schemes"
+	"testing"
+)
+
+// the benchmarks cover every scheme registered with circl so new schemes are included automatically
+
+func BenchmarkKemWrapper(b *testing.B) {
+	for _, s := range kem_schemes.All() {
+		scheme := WrapKem(s)
+		b.Run(scheme.Name(), func(b *testing.B) {
+			pk, k, err := scheme.GenerateKeyPair()
+			if err != nil {
+				b.Fatal(err)
+			}
+			ctxt, _, err := scheme.Encapsulate(pk)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run("GenerateKeyPair", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _, _ = scheme.GenerateKeyPair()
+				}
+			})
+			b.Run("Encapsulate", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _, _ = scheme.Encapsulate(pk)
+				}
+			})
+			b.Run("AppendEncapsulate", func(b *testing.B) {
+				b.ReportAllocs()
+				ct := make([]byte, 0, scheme.CiphertextSize())
+				ss := make([]byte, 0, scheme.SharedKeySize())
+				for b.Loop() {
+					ct, ss, _ = scheme.AppendEncapsulate(ct[:0], ss[:0], pk)
+				}
+			})
+			b.Run("MarshalBinary", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _ = pk.MarshalBinary()
+				}
+			})
+			b.Run("AppendBinary", func(b *testing.B) {
+				b.ReportAllocs()
+				buf := make([]byte, 0, scheme.PublicKeySize())
+				for b.Loop() {
+					buf, _ = pk.(*KemPublicKeyWrapper).AppendBinary(buf[:0])
+				}
+			})
+			b.Run("Decapsulate", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _ = scheme.Decapsulate(k, ctxt)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSigWrapper(b *testing.B) {
+	msg := make([]byte, 1184)
+	for _, s := range sign_schemes.All() {
+		scheme := WrapSig(s)
+		b.Run(scheme.Name(), func(b *testing.B) {
+			pk, k, err := scheme.GenerateKeyPair()
+			if err != nil {
+				b.Fatal(err)
+			}
+			stxt, err := scheme.Sign(k, msg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run("GenerateKeyPair", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _, _ = scheme.GenerateKeyPair()
+				}
+			})
+			b.Run("Sign", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _ = scheme.Sign(k, msg)
+				}
+			})
+			b.Run("AppendSign", func(b *testing.B) {
+				b.ReportAllocs()
+				sig := make([]byte, 0, scheme.SignatureSize())
+				for b.Loop() {
+					sig, _ = scheme.AppendSign(sig[:0], k, msg)
+				}
+			})
+			b.Run("MarshalBinary", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _ = pk.MarshalBinary()
+				}
+			})
+			b.Run("AppendBinary", func(b *testing.B) {
+				b.ReportAllocs()
+				buf := make([]byte, 0, scheme.PublicKeySize())
+				for b.Loop() {
+					buf, _ = pk.(*SigPublicKeyWrapper).AppendBinary(buf[:0])
+				}
+			})
+			b.Run("Verify", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, _ = scheme.Verify(pk, msg, stxt)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/frodo/frodo640shake"
+	"github.com/cloudflare/circl/kem/kyber/kyber1024"
+	"github.com/cloudflare/circl/kem/kyber/kyber512"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/dilithium/mode2"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+	"github.com/cloudflare/circl/sign/dilithium/mode5"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"strings"
+)
+
+// knownKemSchemes are resolved by KemSchemeByName without having been wrapped first
+var knownKemSchemes = []kem.Scheme{
+	mlkem512.Scheme(),
+	mlkem768.Scheme(),
+	mlkem1024.Scheme(),
+	kyber512.Scheme(),
+	kyber768.Scheme(),
+	kyber1024.Scheme(),
+	frodo640shake.Scheme(),
+}
+
+// knownSigSchemes are resolved by SigSchemeByName without having been wrapped first
+var knownSigSchemes = []sign.Scheme{
+	mldsa44.Scheme(),
+	mldsa65.Scheme(),
+	mldsa87.Scheme(),
+	mode2.Scheme(),
+	mode3.Scheme(),
+	mode5.Scheme(),
+}
+
+// KemSchemeByName returns the wrapper with the given (case-insensitive) name, checking schemes passed to WrapKem
+// before the schemes known to this package, nil is returned if there is no such scheme or it is refused in FIPS mode
+func KemSchemeByName(name string) *KemWrapper {
+	name = strings.ToLower(name)
+	if w, ok := kemNamedMap.Load(name); ok {
+		if FIPSMode() && w.(*KemWrapper).fipsCheck() != nil {
+			return nil
+		}
+		return w.(*KemWrapper)
+	}
+	for _, scheme := range knownKemSchemes {
+		if strings.ToLower(scheme.Name()) == name {
+			w, _ := TryWrapKem(scheme)
+			return w
+		}
+	}
+	return nil
+}
+
+// SigSchemeByName returns the wrapper with the given (case-insensitive) name, checking schemes passed to WrapSig
+// before the schemes known to this package, nil is returned if there is no such scheme or it is refused in FIPS mode
+func SigSchemeByName(name string) *SigWrapper {
+	name = strings.ToLower(name)
+	if w, ok := sigNamedMap.Load(name); ok {
+		if FIPSMode() && w.(*SigWrapper).fipsCheck() != nil {
+			return nil
+		}
+		return w.(*SigWrapper)
+	}
+	for _, scheme := range knownSigSchemes {
+		if strings.ToLower(scheme.Name()) == name {
+			w, _ := TryWrapSig(scheme)
+			return w
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"golang.org/x/crypto/scrypt"
+	"io"
+)
+
+// Sealed private keys are encrypted with AES-256-GCM under a key derived from a passphrase with scrypt, the header
+// is authenticated as additional data:
+//
+//	"PQSK" [version] [kind] [name length][scheme name] [kdf] [logN] [r uint32] [p uint32] [salt 16] [nonce 12]
+//	[ciphertext]
+
+const (
+	SealedKeyVersion = 1
+
+	sealedKeyMagic   = "PQSK"
+	sealedKeySalt    = 16
+	sealedKeyNonce   = 12
+	sealedKeyAESSize = 32
+
+	kdfScrypt = 1
+
+	// limits so a crafted header can't make scrypt use excessive memory (128 * r * 2^logN bytes) or time
+	maxScryptMemory = 256 << 20
+	maxScryptP      = 4
+)
+
+// SealedKeyKind is the type of private key in a sealed key
+type SealedKeyKind byte
+
+const (
+	SealedKemPrivateKey SealedKeyKind = 1
+	SealedSigPrivateKey SealedKeyKind = 2
+)
+
+var ErrScryptParams = errors.New("invalid scrypt parameters")
+var ErrInvalidSealedKey = errors.New("invalid sealed key")
+var ErrUnsupportedSealedKey = errors.New("unsupported sealed key version or KDF")
+var ErrDecryptionFailed = errors.New("sealed key decryption failed, wrong passphrase or damaged data")
+
+// ScryptParams are the scrypt cost parameters, N = 2^LogN
+type ScryptParams struct {
+	LogN uint8
+	R    uint32
+	P    uint32
+}
+
+// DefaultScryptParams uses 128 MiB of memory for interactive passphrases
+var DefaultScryptParams = ScryptParams{LogN: 17, R: 8, P: 1}
+
+// Check returns ErrScryptParams unless the parameters are valid and use at most 256 MiB of memory with p at most 4
+func (p ScryptParams) Check() error {
+	if p.LogN < 1 || p.LogN > 30 || p.R < 1 || p.P < 1 || p.P > maxScryptP || uint64(p.R) > (maxScryptMemory/128)>>p.LogN {
+		return ErrScryptParams
+	}
+	return nil
+}
+
+// SealedKeyHeader is the unencrypted part of a sealed key
+type SealedKeyHeader struct {
+	Version uint8
+	Kind    SealedKeyKind
+	Scheme  string
+	Scrypt  ScryptParams
+	Salt    []byte
+	Nonce   []byte
+}
+
+func (h *SealedKeyHeader) MarshalBinary() ([]byte, error) {
+	if len(h.Scheme) == 0 || len(h.Scheme) > maxTagNameLength || len(h.Salt) != sealedKeySalt || len(h.Nonce) != sealedKeyNonce {
+		return nil, ErrInvalidSealedKey
+	}
+	b := make([]byte, 0, len(sealedKeyMagic)+4+len(h.Scheme)+9+sealedKeySalt+sealedKeyNonce)
+	b = append(b, sealedKeyMagic...)
+	b = append(b, byte(h.Version), byte(h.Kind), byte(len(h.Scheme)))
+	b = append(b, h.Scheme...)
+	b = append(b, kdfScrypt, h.Scrypt.LogN)
+	b = binary.BigEndian.AppendUint32(b, h.Scrypt.R)
+	b = binary.BigEndian.AppendUint32(b, h.Scrypt.P)
+	b = append(b, h.Salt...)
+	return append(b, h.Nonce...), nil
+}
+
+// ParseSealedKeyHeader reads the header of a sealed key, returning the number of bytes read
+func ParseSealedKeyHeader(sealed []byte) (*SealedKeyHeader, int, error) {
+	r := bytes.NewReader(sealed)
+	var fixed [len(sealedKeyMagic) + 3]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil || string(fixed[:len(sealedKeyMagic)]) != sealedKeyMagic {
+		return nil, 0, ErrInvalidSealedKey
+	}
+	h := &SealedKeyHeader{Version: fixed[4], Kind: SealedKeyKind(fixed[5])}
+	if h.Version != SealedKeyVersion {
+		return nil, 0, ErrUnsupportedSealedKey
+	}
+	if h.Kind != SealedKemPrivateKey && h.Kind != SealedSigPrivateKey {
+		return nil, 0, ErrInvalidSealedKey
+	}
+	name := make([]byte, fixed[6])
+	var kdf [10]byte
+	h.Salt = make([]byte, sealedKeySalt)
+	h.Nonce = make([]byte, sealedKeyNonce)
+	for _, field := range [][]byte{name, kdf[:], h.Salt, h.Nonce} {
+		if _, err := io.ReadFull(r, field); err != nil {
+			return nil, 0, ErrInvalidSealedKey
+		}
+	}
+	if len(name) == 0 {
+		return nil, 0, ErrInvalidSealedKey
+	}
+	h.Scheme = string(name)
+	if kdf[0] != kdfScrypt {
+		return nil, 0, ErrUnsupportedSealedKey
+	}
+	h.Scrypt = ScryptParams{LogN: kdf[1], R: binary.BigEndian.Uint32(kdf[2:]), P: binary.BigEndian.Uint32(kdf[6:])}
+	return h, len(sealed) - r.Len(), nil
+}
+
+func (h *SealedKeyHeader) aead(passphrase []byte) (cipher.AEAD, error) {
+	if err := h.Scrypt.Check(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, h.Salt, 1<<h.Scrypt.LogN, int(h.Scrypt.R), int(h.Scrypt.P), sealedKeyAESSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealPrivateKey encrypts a crypto.KemPrivateKey or crypto.SigPrivateKey with a passphrase
+func SealPrivateKey(key encoding.BinaryMarshaler, passphrase []byte, params ScryptParams) ([]byte, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if err := params.Check(); err != nil {
+		return nil, err
+	}
+	h := &SealedKeyHeader{Version: SealedKeyVersion, Scrypt: params}
+	switch key.(type) {
+	case crypto.KemPrivateKey:
+		h.Kind = SealedKemPrivateKey
+	case crypto.SigPrivateKey:
+		h.Kind = SealedSigPrivateKey
+	default:
+		return nil, crypto.ErrIncompatibleKey
+	}
+	var err error
+	h.Scheme, err = KeySchemeName(key)
+	if err != nil {
+		return nil, err
+	}
+	h.Salt = make([]byte, sealedKeySalt)
+	h.Nonce = make([]byte, sealedKeyNonce)
+	if _, err = rand.Read(h.Salt); err != nil {
+		return nil, err
+	}
+	if _, err = rand.Read(h.Nonce); err != nil {
+		return nil, err
+	}
+	header, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plain)
+	return aead.Seal(header, h.Nonce, plain, header), nil
+}
+
+// openSealedKey decrypts the private key bytes of a sealed key of the given kind
+func openSealedKey(sealed, passphrase []byte, kind SealedKeyKind) (*SealedKeyHeader, []byte, error) {
+	h, n, err := ParseSealedKeyHeader(sealed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if h.Kind != kind {
+		return nil, nil, crypto.ErrIncompatibleKey
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := aead.Open(nil, h.Nonce, sealed[n:], sealed[:n])
+	if err != nil {
+		return nil, nil, ErrDecryptionFailed
+	}
+	return h, plain, nil
+}
+
+// OpenKemPrivateKey decrypts a sealed KEM private key, the scheme is resolved with KemSchemeByName
+func OpenKemPrivateKey(sealed, passphrase []byte) (crypto.KemPrivateKey, error) {
+	h, plain, err := openSealedKey(sealed, passphrase, SealedKemPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plain)
+	scheme := KemSchemeByName(h.Scheme)
+	if scheme == nil {
+		return nil, ErrUnknownScheme
+	}
+	return scheme.UnmarshalBinaryPrivateKey(plain)
+}
+
+// OpenSigPrivateKey decrypts a sealed signature private key, the scheme is resolved with SigSchemeByName
+func OpenSigPrivateKey(sealed, passphrase []byte) (crypto.SigPrivateKey, error) {
+	h, plain, err := openSealedKey(sealed, passphrase, SealedSigPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plain)
+	scheme := SigSchemeByName(h.Scheme)
+	if scheme == nil {
+		return nil, ErrUnknownScheme
+	}
+	return scheme.UnmarshalBinaryPrivateKey(plain)
+}
@@ -0,0 +1,95 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testScryptParams = ScryptParams{LogN: 10, R: 8, P: 1}
+
+func TestSealedKemPrivateKey(t *testing.T) {
+	pk, k, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	sealed, err := SealPrivateKey(k, []byte("passphrase"), testScryptParams)
+	assert.NoError(t, err)
+	h, _, err := ParseSealedKeyHeader(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(SealedKeyVersion), h.Version)
+	assert.Equal(t, SealedKemPrivateKey, h.Kind)
+	assert.Equal(t, "ML-KEM-768", h.Scheme)
+	assert.Equal(t, testScryptParams, h.Scrypt)
+	rk, err := OpenKemPrivateKey(sealed, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.True(t, k.Equals(rk))
+	_, err = OpenKemPrivateKey(sealed, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = OpenSigPrivateKey(sealed, []byte("passphrase"))
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+	_, err = SealPrivateKey(pk, []byte("passphrase"), testScryptParams)
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+}
+
+func TestSealedSigPrivateKey(t *testing.T) {
+	_, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	sealed, err := SealPrivateKey(k, []byte("passphrase"), testScryptParams)
+	assert.NoError(t, err)
+	rk, err := OpenSigPrivateKey(sealed, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.True(t, k.Equals(rk))
+	_, err = OpenKemPrivateKey(sealed, []byte("passphrase"))
+	assert.ErrorIs(t, err, crypto.ErrIncompatibleKey)
+}
+
+func TestSealedKeyTampered(t *testing.T) {
+	_, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	sealed, err := SealPrivateKey(k, []byte("passphrase"), testScryptParams)
+	assert.NoError(t, err)
+	_, n, err := ParseSealedKeyHeader(sealed)
+	assert.NoError(t, err)
+	for name, idx := range map[string]int{"Header salt": n - sealedKeyNonce - 1, "Ciphertext": n + 10, "Tag": len(sealed) - 1} {
+		t.Run(name, func(t *testing.T) {
+			damaged := append([]byte{}, sealed...)
+			damaged[idx] ^= 0xff
+			_, err := OpenSigPrivateKey(damaged, []byte("passphrase"))
+			assert.ErrorIs(t, err, ErrDecryptionFailed)
+		})
+	}
+	_, err = OpenSigPrivateKey(sealed[:n-1], []byte("passphrase"))
+	assert.ErrorIs(t, err, ErrInvalidSealedKey)
+	_, err = OpenSigPrivateKey([]byte("PQSK\x02\x02\x09"), []byte("passphrase"))
+	assert.ErrorIs(t, err, ErrUnsupportedSealedKey)
+	costly := append([]byte{}, sealed...)
+	costly[len(sealedKeyMagic)+3+len("ML-DSA-44")+1] = 20
+	_, err = OpenSigPrivateKey(costly, []byte("passphrase"))
+	assert.ErrorIs(t, err, ErrScryptParams)
+}
+
+func TestScryptParamsCheck(t *testing.T) {
+	assert.NoError(t, DefaultScryptParams.Check())
+	assert.NoError(t, testScryptParams.Check())
+	assert.NoError(t, ScryptParams{LogN: 18, R: 8, P: 4}.Check())
+	assert.NoError(t, ScryptParams{LogN: 21, R: 1, P: 1}.Check())
+	for _, p := range []ScryptParams{
+		{LogN: 0, R: 8, P: 1},
+		{LogN: 10, R: 0, P: 1},
+		{LogN: 10, R: 8, P: 0},
+		{LogN: 10, R: 8, P: 5},
+		{LogN: 19, R: 8, P: 1},
+		{LogN: 22, R: 1, P: 1},
+		{LogN: 20, R: 32, P: 16},
+		{LogN: 10, R: 1 << 31, P: 1},
+	} {
+		assert.ErrorIs(t, p.Check(), ErrScryptParams, "%+v", p)
+	}
+	_, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = SealPrivateKey(k, []byte("passphrase"), ScryptParams{LogN: 20, R: 8, P: 1})
+	assert.ErrorIs(t, err, ErrScryptParams)
+}
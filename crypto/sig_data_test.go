@@ -95,3 +95,43 @@ func damageMeta(d *crypto.SigData) *crypto.SigData {
 	d.ExpiryTime = d.ExpiryTime.Add(-time.Minute)
 	return d
 }
+
+func BenchmarkSigData(b *testing.B) {
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	if err != nil {
+		b.Fatal(err)
+	}
+	sk, _, err := WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	if err != nil {
+		b.Fatal(err)
+	}
+	skb, err := sk.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	var modes = []struct {
+		name  string
+		tHash hash.Hash
+	}{{"Hash", sha256.New()}, {"Full data", nil}}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			b.Run("NewSigData", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_ = crypto.NewSigData(skb, time.Now(), time.Now().Add(time.Hour), mode.tHash, k)
+				}
+			})
+			sData := crypto.NewSigData(skb, time.Now(), time.Now().Add(time.Hour), mode.tHash, k)
+			if sData == nil {
+				b.Fatal("nil SigData")
+			}
+			b.Run("Verify", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_ = sData.Verify(mode.tHash, pk)
+				}
+			})
+		})
+	}
+}
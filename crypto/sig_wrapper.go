@@ -3,45 +3,82 @@
 package crypto
 
 import (
+	"fmt"
 	"github.com/1f349/handshake/crypto"
 	"github.com/cloudflare/circl/sign"
+	"strings"
 	"sync"
 )
 
-var sigWrappedMap = make(map[sign.Scheme]*SigWrapper)
-var slockSigWrappedMap = &sync.RWMutex{}
+// sigWrappedMap maps sign.Scheme to *SigWrapper, sync.Map is used as entries are only ever added once and then
+// read on every call to WrapSig and Scheme()
+var sigWrappedMap = &sync.Map{}
+
+// sigNamedMap maps the lower case scheme name to *SigWrapper for SigSchemeByName
+var sigNamedMap = &sync.Map{}
 
 func getSigWrapper(scheme sign.Scheme) *SigWrapper {
-	slockSigWrappedMap.RLock()
-	defer slockSigWrappedMap.RUnlock()
-	sigWrapped, ok := sigWrappedMap[scheme]
-	if !ok {
-		return nil
+	if w, ok := sigWrappedMap.Load(scheme); ok {
+		return w.(*SigWrapper)
 	}
-	return sigWrapped
+	return nil
+}
+
+// WrapSig a sign.Scheme, the same *SigWrapper is returned for every call with the same scheme. This never fails, in
+// FIPS mode the wrapper methods return ErrNotFIPSApproved for schemes that are not approved, see TryWrapSig
+func WrapSig(scheme sign.Scheme) *SigWrapper {
+	return registerSig(scheme)
 }
 
-func addSigWrapper(scheme sign.Scheme) *SigWrapper {
-	slockSigWrappedMap.Lock()
-	defer slockSigWrappedMap.Unlock()
-	if _, ok := sigWrappedMap[scheme]; !ok {
-		sigWrappedMap[scheme] = &SigWrapper{scheme}
+// TryWrapSig is WrapSig returning ErrNotFIPSApproved in FIPS mode for schemes that are not approved or fail their
+// self-test
+func TryWrapSig(scheme sign.Scheme) (*SigWrapper, error) {
+	w := registerSig(scheme)
+	if FIPSMode() {
+		if err := w.fipsCheck(); err != nil {
+			return nil, err
+		}
 	}
-	return sigWrappedMap[scheme]
+	return w, nil
 }
 
-// WrapSig a sign.Scheme
-func WrapSig(scheme sign.Scheme) *SigWrapper {
-	w := getSigWrapper(scheme)
-	if w == nil {
-		return addSigWrapper(scheme)
+// registerSig returns the registered wrapper for scheme, adding it if needed, without any FIPS mode checks
+func registerSig(scheme sign.Scheme) *SigWrapper {
+	if w := getSigWrapper(scheme); w != nil {
+		return w
+	}
+	w, loaded := sigWrappedMap.LoadOrStore(scheme, &SigWrapper{
+		wrapped:  scheme,
+		selfTest: &selfTest{},
+	})
+	if !loaded {
+		sigNamedMap.LoadOrStore(strings.ToLower(scheme.Name()), w)
 	}
-	return w
+	return w.(*SigWrapper)
+}
+
+// fipsCheck refuses schemes that are not approved and runs the self-test once
+func (s *SigWrapper) fipsCheck() error {
+	if !fipsApproved(s.Name()) {
+		return fmt.Errorf("%w: %s", ErrNotFIPSApproved, s.Name())
+	}
+	return s.selfTest.run(func() error {
+		return sigSelfTest(s.wrapped)
+	})
+}
+
+// modeCheck is fipsCheck in FIPS mode, so wrappers made before FIPS mode was turned on or with WrapSig are refused too
+func (s *SigWrapper) modeCheck() error {
+	if !FIPSMode() {
+		return nil
+	}
+	return s.fipsCheck()
 }
 
 // SigWrapper wraps sign.Scheme from github.com/cloudflare/circl for SigScheme
 type SigWrapper struct {
-	wrapped sign.Scheme
+	wrapped  sign.Scheme
+	selfTest *selfTest
 }
 
 func (s SigWrapper) Name() string {
@@ -49,6 +86,9 @@ func (s SigWrapper) Name() string {
 }
 
 func (s SigWrapper) GenerateKeyPair() (crypto.SigPublicKey, crypto.SigPrivateKey, error) {
+	if err := s.modeCheck(); err != nil {
+		return nil, nil, err
+	}
 	p, q, err := s.wrapped.GenerateKey()
 	if err != nil {
 		return nil, nil, err
@@ -56,7 +96,22 @@ func (s SigWrapper) GenerateKeyPair() (crypto.SigPublicKey, crypto.SigPrivateKey
 	return &SigPublicKeyWrapper{p}, &SigPrivateKeyWrapper{q}, nil
 }
 
+// DeriveKeyPair deterministically derives a key pair from a seed of SeedSize bytes
+func (s SigWrapper) DeriveKeyPair(seed []byte) (crypto.SigPublicKey, crypto.SigPrivateKey, error) {
+	if err := s.modeCheck(); err != nil {
+		return nil, nil, err
+	}
+	if len(seed) != s.wrapped.SeedSize() {
+		return nil, nil, sign.ErrSeedSize
+	}
+	p, q := s.wrapped.DeriveKey(seed)
+	return &SigPublicKeyWrapper{p}, &SigPrivateKeyWrapper{q}, nil
+}
+
 func (s SigWrapper) UnmarshalBinaryPrivateKey(bytes []byte) (crypto.SigPrivateKey, error) {
+	if err := s.modeCheck(); err != nil {
+		return nil, err
+	}
 	wk, err := s.wrapped.UnmarshalBinaryPrivateKey(bytes)
 	if err != nil {
 		return nil, err
@@ -65,6 +120,9 @@ func (s SigWrapper) UnmarshalBinaryPrivateKey(bytes []byte) (crypto.SigPrivateKe
 }
 
 func (s SigWrapper) UnmarshalBinaryPublicKey(bytes []byte) (crypto.SigPublicKey, error) {
+	if err := s.modeCheck(); err != nil {
+		return nil, err
+	}
 	wk, err := s.wrapped.UnmarshalBinaryPublicKey(bytes)
 	if err != nil {
 		return nil, err
@@ -73,6 +131,9 @@ func (s SigWrapper) UnmarshalBinaryPublicKey(bytes []byte) (crypto.SigPublicKey,
 }
 
 func (s SigWrapper) Sign(key crypto.SigPrivateKey, msg []byte) (stxt []byte, err error) {
+	if err := s.modeCheck(); err != nil {
+		return nil, err
+	}
 	if key == nil {
 		return nil, crypto.ErrKeyNil
 	}
@@ -92,6 +153,9 @@ func (s SigWrapper) Sign(key crypto.SigPrivateKey, msg []byte) (stxt []byte, err
 }
 
 func (s SigWrapper) Verify(key crypto.SigPublicKey, msg []byte, stxt []byte) (v bool, err error) {
+	if err := s.modeCheck(); err != nil {
+		return false, err
+	}
 	if key == nil {
 		return false, crypto.ErrKeyNil
 	}
@@ -122,13 +186,17 @@ func (s SigWrapper) SignatureSize() int {
 	return s.wrapped.SignatureSize()
 }
 
+func (s SigWrapper) SeedSize() int {
+	return s.wrapped.SeedSize()
+}
+
 // SigPublicKeyWrapper wraps sign.PublicKey  for SigPublicKey
 type SigPublicKeyWrapper struct {
 	sign.PublicKey
 }
 
 func (k SigPublicKeyWrapper) Scheme() crypto.SigScheme {
-	return getSigWrapper(k.PublicKey.Scheme())
+	return registerSig(k.PublicKey.Scheme())
 }
 
 func (k SigPublicKeyWrapper) Equals(key crypto.SigPublicKey) bool {
@@ -147,7 +215,7 @@ type SigPrivateKeyWrapper struct {
 }
 
 func (k SigPrivateKeyWrapper) Scheme() crypto.SigScheme {
-	return getSigWrapper(k.PrivateKey.Scheme())
+	return registerSig(k.PrivateKey.Scheme())
 }
 
 func (k SigPrivateKeyWrapper) Equals(key crypto.SigPrivateKey) bool {
@@ -0,0 +1,158 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"encoding"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"reflect"
+)
+
+// Tagged keys are the scheme name, prefixed by its length as a single byte, followed by the binary key:
+//
+//	[name length][name][key]
+//
+// so they can be read back without knowing the scheme ahead of time.
+
+var ErrInvalidTag = errors.New("invalid scheme tag")
+var ErrUnknownScheme = errors.New("unknown scheme")
+
+const maxTagNameLength = 255
+
+// MarshalTagged encodes a crypto.KemPublicKey, crypto.KemPrivateKey, crypto.SigPublicKey or crypto.SigPrivateKey
+// prefixed with its scheme name
+func MarshalTagged(key encoding.BinaryMarshaler) ([]byte, error) {
+	name, err := KeySchemeName(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(name) == 0 || len(name) > maxTagNameLength {
+		return nil, ErrInvalidTag
+	}
+	bts, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	tagged := make([]byte, 0, 1+len(name)+len(bts))
+	tagged = append(tagged, byte(len(name)))
+	tagged = append(tagged, name...)
+	return append(tagged, bts...), nil
+}
+
+// KeySchemeName returns the scheme name of a crypto.KemPublicKey, crypto.KemPrivateKey, crypto.SigPublicKey or
+// crypto.SigPrivateKey
+func KeySchemeName(key encoding.BinaryMarshaler) (string, error) {
+	if nilKey(key) {
+		return "", crypto.ErrKeyNil
+	}
+	switch k := key.(type) {
+	case crypto.KemPublicKey:
+		if s := k.Scheme(); s != nil {
+			return s.Name(), nil
+		}
+	case crypto.KemPrivateKey:
+		if s := k.Scheme(); s != nil {
+			return s.Name(), nil
+		}
+	case crypto.SigPublicKey:
+		if s := k.Scheme(); s != nil {
+			return s.Name(), nil
+		}
+	case crypto.SigPrivateKey:
+		if s := k.Scheme(); s != nil {
+			return s.Name(), nil
+		}
+	default:
+		return "", crypto.ErrIncompatibleKey
+	}
+	return "", ErrUnknownScheme
+}
+
+// nilKey is true for a nil key, a nil pointer or a wrapper around a nil key
+func nilKey(key encoding.BinaryMarshaler) bool {
+	switch k := key.(type) {
+	case nil:
+		return true
+	case *KemPublicKeyWrapper:
+		return k == nil || k.PublicKey == nil
+	case *KemPrivateKeyWrapper:
+		return k == nil || k.PrivateKey == nil
+	case *SigPublicKeyWrapper:
+		return k == nil || k.PublicKey == nil
+	case *SigPrivateKeyWrapper:
+		return k == nil || k.PrivateKey == nil
+	}
+	v := reflect.ValueOf(key)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// SplitTagged returns the scheme name and binary key of a tagged key
+func SplitTagged(tagged []byte) (name string, key []byte, err error) {
+	if len(tagged) < 1 || tagged[0] == 0 || len(tagged) < 1+int(tagged[0]) {
+		return "", nil, ErrInvalidTag
+	}
+	return string(tagged[1 : 1+tagged[0]]), tagged[1+tagged[0]:], nil
+}
+
+// TaggedKemScheme returns the scheme a tagged KEM key was marshalled with
+func TaggedKemScheme(tagged []byte) (*KemWrapper, []byte, error) {
+	name, key, err := SplitTagged(tagged)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := KemSchemeByName(name)
+	if scheme == nil {
+		return nil, nil, ErrUnknownScheme
+	}
+	return scheme, key, nil
+}
+
+// TaggedSigScheme returns the scheme a tagged signature key was marshalled with
+func TaggedSigScheme(tagged []byte) (*SigWrapper, []byte, error) {
+	name, key, err := SplitTagged(tagged)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := SigSchemeByName(name)
+	if scheme == nil {
+		return nil, nil, ErrUnknownScheme
+	}
+	return scheme, key, nil
+}
+
+// UnmarshalAnyKemPublicKey unmarshals a tagged KEM public key using the scheme named in the tag
+func UnmarshalAnyKemPublicKey(tagged []byte) (crypto.KemPublicKey, error) {
+	scheme, key, err := TaggedKemScheme(tagged)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.UnmarshalBinaryPublicKey(key)
+}
+
+// UnmarshalAnyKemPrivateKey unmarshals a tagged KEM private key using the scheme named in the tag
+func UnmarshalAnyKemPrivateKey(tagged []byte) (crypto.KemPrivateKey, error) {
+	scheme, key, err := TaggedKemScheme(tagged)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.UnmarshalBinaryPrivateKey(key)
+}
+
+// UnmarshalAnySigPublicKey unmarshals a tagged signature public key using the scheme named in the tag
+func UnmarshalAnySigPublicKey(tagged []byte) (crypto.SigPublicKey, error) {
+	scheme, key, err := TaggedSigScheme(tagged)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.UnmarshalBinaryPublicKey(key)
+}
+
+// UnmarshalAnySigPrivateKey unmarshals a tagged signature private key using the scheme named in the tag
+func UnmarshalAnySigPrivateKey(tagged []byte) (crypto.SigPrivateKey, error) {
+	scheme, key, err := TaggedSigScheme(tagged)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.UnmarshalBinaryPrivateKey(key)
+}
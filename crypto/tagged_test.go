@@ -0,0 +1,88 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"encoding"
+	"github.com/1f349/handshake/crypto"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTaggedKem(t *testing.T) {
+	for _, s := range knownKemSchemes {
+		scheme := WrapKem(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			tpk, err := MarshalTagged(pk)
+			assert.NoError(t, err)
+			tk, err := MarshalTagged(k)
+			assert.NoError(t, err)
+			rpk, err := UnmarshalAnyKemPublicKey(tpk)
+			assert.NoError(t, err)
+			assert.True(t, pk.Equals(rpk))
+			assert.Same(t, scheme, rpk.Scheme())
+			rk, err := UnmarshalAnyKemPrivateKey(tk)
+			assert.NoError(t, err)
+			assert.True(t, k.Equals(rk))
+			_, err = UnmarshalAnySigPublicKey(tpk)
+			assert.ErrorIs(t, err, ErrUnknownScheme)
+		})
+	}
+}
+
+func TestTaggedSig(t *testing.T) {
+	for _, s := range knownSigSchemes {
+		scheme := WrapSig(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			tpk, err := MarshalTagged(pk)
+			assert.NoError(t, err)
+			tk, err := MarshalTagged(k)
+			assert.NoError(t, err)
+			rpk, err := UnmarshalAnySigPublicKey(tpk)
+			assert.NoError(t, err)
+			assert.True(t, pk.Equals(rpk))
+			assert.Same(t, scheme, rpk.Scheme())
+			rk, err := UnmarshalAnySigPrivateKey(tk)
+			assert.NoError(t, err)
+			assert.True(t, k.Equals(rk))
+			_, err = UnmarshalAnyKemPublicKey(tpk)
+			assert.ErrorIs(t, err, ErrUnknownScheme)
+		})
+	}
+}
+
+func TestTaggedInvalid(t *testing.T) {
+	_, err := MarshalTagged(nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	for _, key := range []encoding.BinaryMarshaler{
+		(*KemPublicKeyWrapper)(nil),
+		(*SigPrivateKeyWrapper)(nil),
+		&SigPublicKeyWrapper{},
+		&KemPrivateKeyWrapper{},
+	} {
+		_, err = MarshalTagged(key)
+		assert.ErrorIs(t, err, crypto.ErrKeyNil)
+		_, err = KeySchemeName(key)
+		assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	}
+	for name, tagged := range map[string][]byte{
+		"Empty":          {},
+		"Empty name":     {0, 1, 2, 3},
+		"Truncated name": {10, 'M', 'L'},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := UnmarshalAnyKemPublicKey(tagged)
+			assert.ErrorIs(t, err, ErrInvalidTag)
+			_, err = UnmarshalAnySigPrivateKey(tagged)
+			assert.ErrorIs(t, err, ErrInvalidTag)
+		})
+	}
+	_, err = UnmarshalAnyKemPublicKey(append([]byte{7}, "Unknown"...))
+	assert.ErrorIs(t, err, ErrUnknownScheme)
+	_, err = UnmarshalAnyKemPublicKey(append([]byte{10}, "ML-KEM-768"...))
+	assert.Error(t, err)
+}
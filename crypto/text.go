@@ -0,0 +1,209 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+)
+
+// Text encoded keys are the scheme name and the base64url (unpadded) binary key separated by a colon:
+//
+//	ML-DSA-44:<base64url key>
+//
+// the scheme is resolved with KemSchemeByName / SigSchemeByName when decoding.
+
+var ErrInvalidKeyText = errors.New("invalid key text")
+
+var jsonNull = []byte("null")
+
+func marshalKeyText(key encoding.BinaryMarshaler) ([]byte, error) {
+	name, err := KeySchemeName(key)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	text := make([]byte, 0, len(name)+1+base64.RawURLEncoding.EncodedLen(len(bts)))
+	text = append(text, name...)
+	text = append(text, ':')
+	return base64.RawURLEncoding.AppendEncode(text, bts), nil
+}
+
+// splitKeyText returns the scheme name and decoded binary key of a text encoded key
+func splitKeyText(text []byte) (string, []byte, error) {
+	name, enc, ok := bytes.Cut(text, []byte{':'})
+	if !ok || len(name) == 0 {
+		return "", nil, ErrInvalidKeyText
+	}
+	bts, err := base64.RawURLEncoding.AppendDecode(nil, enc)
+	if err != nil {
+		return "", nil, ErrInvalidKeyText
+	}
+	return string(name), bts, nil
+}
+
+func kemTextScheme(text []byte) (*KemWrapper, []byte, error) {
+	name, bts, err := splitKeyText(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := KemSchemeByName(name)
+	if scheme == nil {
+		return nil, nil, ErrUnknownScheme
+	}
+	return scheme, bts, nil
+}
+
+func sigTextScheme(text []byte) (*SigWrapper, []byte, error) {
+	name, bts, err := splitKeyText(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := SigSchemeByName(name)
+	if scheme == nil {
+		return nil, nil, ErrUnknownScheme
+	}
+	return scheme, bts, nil
+}
+
+// marshalKeyJSON encodes the text form as a JSON string
+func marshalKeyJSON(key encoding.TextMarshaler) ([]byte, error) {
+	text, err := key.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// unmarshalKeyJSON decodes a JSON string into key, null leaves key unchanged
+func unmarshalKeyJSON(data []byte, key encoding.TextUnmarshaler) error {
+	if bytes.Equal(data, jsonNull) {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return key.UnmarshalText([]byte(text))
+}
+
+func (k KemPublicKeyWrapper) MarshalText() ([]byte, error) {
+	if k.PublicKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return marshalKeyText(k)
+}
+
+func (k *KemPublicKeyWrapper) UnmarshalText(text []byte) error {
+	scheme, bts, err := kemTextScheme(text)
+	if err != nil {
+		return err
+	}
+	wk, err := scheme.wrapped.UnmarshalBinaryPublicKey(bts)
+	if err != nil {
+		return err
+	}
+	k.PublicKey = wk
+	return nil
+}
+
+func (k KemPublicKeyWrapper) MarshalJSON() ([]byte, error) {
+	return marshalKeyJSON(k)
+}
+
+func (k *KemPublicKeyWrapper) UnmarshalJSON(data []byte) error {
+	return unmarshalKeyJSON(data, k)
+}
+
+func (k KemPrivateKeyWrapper) MarshalText() ([]byte, error) {
+	if k.PrivateKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return marshalKeyText(k)
+}
+
+func (k *KemPrivateKeyWrapper) UnmarshalText(text []byte) error {
+	scheme, bts, err := kemTextScheme(text)
+	if err != nil {
+		return err
+	}
+	defer clear(bts)
+	wk, err := scheme.wrapped.UnmarshalBinaryPrivateKey(bts)
+	if err != nil {
+		return err
+	}
+	k.PrivateKey = wk
+	return nil
+}
+
+func (k KemPrivateKeyWrapper) MarshalJSON() ([]byte, error) {
+	return marshalKeyJSON(k)
+}
+
+func (k *KemPrivateKeyWrapper) UnmarshalJSON(data []byte) error {
+	return unmarshalKeyJSON(data, k)
+}
+
+func (k SigPublicKeyWrapper) MarshalText() ([]byte, error) {
+	if k.PublicKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return marshalKeyText(k)
+}
+
+func (k *SigPublicKeyWrapper) UnmarshalText(text []byte) error {
+	scheme, bts, err := sigTextScheme(text)
+	if err != nil {
+		return err
+	}
+	wk, err := scheme.wrapped.UnmarshalBinaryPublicKey(bts)
+	if err != nil {
+		return err
+	}
+	k.PublicKey = wk
+	return nil
+}
+
+func (k SigPublicKeyWrapper) MarshalJSON() ([]byte, error) {
+	return marshalKeyJSON(k)
+}
+
+func (k *SigPublicKeyWrapper) UnmarshalJSON(data []byte) error {
+	return unmarshalKeyJSON(data, k)
+}
+
+func (k SigPrivateKeyWrapper) MarshalText() ([]byte, error) {
+	if k.PrivateKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	return marshalKeyText(k)
+}
+
+func (k *SigPrivateKeyWrapper) UnmarshalText(text []byte) error {
+	scheme, bts, err := sigTextScheme(text)
+	if err != nil {
+		return err
+	}
+	defer clear(bts)
+	wk, err := scheme.wrapped.UnmarshalBinaryPrivateKey(bts)
+	if err != nil {
+		return err
+	}
+	k.PrivateKey = wk
+	return nil
+}
+
+func (k SigPrivateKeyWrapper) MarshalJSON() ([]byte, error) {
+	return marshalKeyJSON(k)
+}
+
+func (k *SigPrivateKeyWrapper) UnmarshalJSON(data []byte) error {
+	return unmarshalKeyJSON(data, k)
+}
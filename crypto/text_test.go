@@ -0,0 +1,117 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestKemKeyText(t *testing.T) {
+	for _, s := range knownKemSchemes {
+		scheme := WrapKem(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			text, err := pk.(*KemPublicKeyWrapper).MarshalText()
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(text), scheme.Name()+":"))
+			var rpk KemPublicKeyWrapper
+			assert.NoError(t, rpk.UnmarshalText(text))
+			assert.True(t, pk.Equals(&rpk))
+			assert.Same(t, scheme, rpk.Scheme())
+
+			text, err = k.(*KemPrivateKeyWrapper).MarshalText()
+			assert.NoError(t, err)
+			var rk KemPrivateKeyWrapper
+			assert.NoError(t, rk.UnmarshalText(text))
+			assert.True(t, k.Equals(&rk))
+
+			var spk SigPublicKeyWrapper
+			assert.ErrorIs(t, spk.UnmarshalText(text), ErrUnknownScheme)
+		})
+	}
+}
+
+func TestSigKeyText(t *testing.T) {
+	for _, s := range knownSigSchemes {
+		scheme := WrapSig(s)
+		t.Run(scheme.Name(), func(t *testing.T) {
+			pk, k, err := scheme.GenerateKeyPair()
+			assert.NoError(t, err)
+			text, err := pk.(*SigPublicKeyWrapper).MarshalText()
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(text), scheme.Name()+":"))
+			var rpk SigPublicKeyWrapper
+			assert.NoError(t, rpk.UnmarshalText(text))
+			assert.True(t, pk.Equals(&rpk))
+			assert.Same(t, scheme, rpk.Scheme())
+
+			text, err = k.(*SigPrivateKeyWrapper).MarshalText()
+			assert.NoError(t, err)
+			var rk SigPrivateKeyWrapper
+			assert.NoError(t, rk.UnmarshalText(text))
+			assert.True(t, k.Equals(&rk))
+
+			var kpk KemPublicKeyWrapper
+			assert.ErrorIs(t, kpk.UnmarshalText(text), ErrUnknownScheme)
+		})
+	}
+}
+
+func TestKeyJSON(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	pkBts, err := pk.MarshalBinary()
+	assert.NoError(t, err)
+
+	type config struct {
+		Peer *SigPublicKeyWrapper `json:"peer"`
+		Key  SigPrivateKeyWrapper `json:"key"`
+		None *SigPublicKeyWrapper `json:"none"`
+	}
+	bts, err := json.Marshal(config{Peer: pk.(*SigPublicKeyWrapper), Key: *k.(*SigPrivateKeyWrapper)})
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `"peer":"ML-DSA-44:`+base64.RawURLEncoding.EncodeToString(pkBts)+`"`)
+	assert.Contains(t, string(bts), `"none":null`)
+
+	var c config
+	assert.NoError(t, json.Unmarshal(bts, &c))
+	assert.True(t, pk.Equals(c.Peer))
+	assert.True(t, k.Equals(&c.Key))
+	assert.Nil(t, c.None)
+
+	// scheme names are case-insensitive
+	var lower SigPublicKeyWrapper
+	assert.NoError(t, json.Unmarshal([]byte(`"ml-dsa-44:`+base64.RawURLEncoding.EncodeToString(pkBts)+`"`), &lower))
+	assert.True(t, pk.Equals(&lower))
+}
+
+func TestKeyTextInvalid(t *testing.T) {
+	_, err := KemPublicKeyWrapper{}.MarshalText()
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	_, err = SigPrivateKeyWrapper{}.MarshalJSON()
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+	var pk SigPublicKeyWrapper
+	for name, text := range map[string]string{
+		"empty":     "",
+		"no colon":  "ML-DSA-44",
+		"no name":   ":AAAA",
+		"padded":    "ML-DSA-44:AA==",
+		"std chars": "ML-DSA-44:a+/b",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, pk.UnmarshalText([]byte(text)), ErrInvalidKeyText)
+		})
+	}
+	assert.ErrorIs(t, pk.UnmarshalText([]byte("ML-DSA-1:AAAA")), ErrUnknownScheme)
+	assert.Error(t, pk.UnmarshalText([]byte("ML-DSA-44:AAAA")))
+	assert.Error(t, json.Unmarshal([]byte(`123`), &pk))
+	assert.Nil(t, pk.PublicKey)
+}
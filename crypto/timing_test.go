@@ -0,0 +1,150 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+//go:build dudect
+
+// Timing leak tests in the style of dudect (https://eprint.iacr.org/2016/1123.pdf).
+//
+// These are long-running and only built with the dudect tag:
+//
+//	go test -tags dudect -run Dudect -timeout 0 ./crypto/ -dudect.samples 1000000
+
+package crypto
+
+import (
+	"crypto/rand"
+	"flag"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"math"
+	mrand "math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+var dudectSamples = flag.Int("dudect.samples", 200000, "number of timing samples taken per dudect test")
+
+// Welch's t-test thresholds used by dudect, above dudectThreshold the two classes are considered to be
+// distinguishable by timing alone.
+const (
+	dudectWarnThreshold = 4.5
+	dudectThreshold     = 10
+)
+
+// welchT accumulates two classes of measurements using Welford's online algorithm
+type welchT struct {
+	n    [2]float64
+	mean [2]float64
+	m2   [2]float64
+}
+
+func (w *welchT) Push(class int, x float64) {
+	w.n[class]++
+	delta := x - w.mean[class]
+	w.mean[class] += delta / w.n[class]
+	w.m2[class] += delta * (x - w.mean[class])
+}
+
+func (w *welchT) T() float64 {
+	if w.n[0] < 2 || w.n[1] < 2 {
+		return 0
+	}
+	v0 := w.m2[0] / (w.n[0] - 1)
+	v1 := w.m2[1] / (w.n[1] - 1)
+	den := math.Sqrt(v0/w.n[0] + v1/w.n[1])
+	if den == 0 {
+		return 0
+	}
+	return (w.mean[0] - w.mean[1]) / den
+}
+
+// dudect measures op for randomly interleaved classes and returns the largest absolute t statistic across the
+// uncropped measurements and a set of percentile crops, as slow outliers (interrupts, GC) swamp small differences.
+func dudect(samples int, op func(class int, idx int)) float64 {
+	classes := make([]int, samples)
+	for i := range classes {
+		classes[i] = mrand.IntN(2)
+	}
+	times := make([]float64, samples)
+	for i := range samples {
+		st := time.Now()
+		op(classes[i], i)
+		times[i] = float64(time.Since(st).Nanoseconds())
+	}
+	sorted := slices.Clone(times)
+	slices.Sort(sorted)
+	crops := []float64{math.Inf(1)}
+	for _, p := range []float64{0.5, 0.75, 0.9, 0.95, 0.99} {
+		crops = append(crops, sorted[int(p*float64(samples-1))])
+	}
+	var maxT float64
+	for _, crop := range crops {
+		var w welchT
+		for i, x := range times {
+			if x <= crop {
+				w.Push(classes[i], x)
+			}
+		}
+		maxT = max(maxT, math.Abs(w.T()))
+	}
+	return maxT
+}
+
+func checkDudect(t *testing.T, tVal float64) {
+	t.Logf("max |t| = %.3f over %d samples", tVal, *dudectSamples)
+	if tVal > dudectWarnThreshold {
+		t.Logf("|t| above %v, possible leak; rerun with more samples", dudectWarnThreshold)
+	}
+	assert.Less(t, tVal, float64(dudectThreshold))
+}
+
+func TestWelchT(t *testing.T) {
+	var same, shifted welchT
+	for i := 0; i < 100000; i++ {
+		same.Push(i%2, mrand.NormFloat64())
+		shifted.Push(i%2, mrand.NormFloat64()+float64(i%2))
+	}
+	assert.Less(t, math.Abs(same.T()), float64(dudectThreshold))
+	assert.Greater(t, math.Abs(shifted.T()), float64(dudectThreshold))
+}
+
+func TestDudectKemDecapsulate(t *testing.T) {
+	scheme := WrapKem(mlkem768.Scheme())
+	pk, k, err := scheme.GenerateKeyPair()
+	assert.NoError(t, err)
+	const pool = 1024
+	ctxts := [2][][]byte{make([][]byte, pool), make([][]byte, pool)}
+	for i := range pool {
+		ctxts[0][i], _, err = scheme.Encapsulate(pk)
+		assert.NoError(t, err)
+		ctxts[1][i] = make([]byte, scheme.CiphertextSize())
+		_, _ = rand.Read(ctxts[1][i])
+	}
+	tVal := dudect(*dudectSamples, func(class int, idx int) {
+		_, _ = scheme.Decapsulate(k, ctxts[class][idx%pool])
+	})
+	checkDudect(t, tVal)
+}
+
+func TestDudectSigSign(t *testing.T) {
+	scheme := WrapSig(mldsa44.Scheme())
+	var keys [2]crypto.SigPrivateKey
+	for i := range keys {
+		var err error
+		_, keys[i], err = scheme.GenerateKeyPair()
+		assert.NoError(t, err)
+	}
+	// ML-DSA signing is a rejection loop whose iteration count depends on (key, message), reusing messages would
+	// give each key its own fixed running time, so a fresh random message is used for every sample.
+	msgs := make([][]byte, *dudectSamples)
+	for i := range msgs {
+		msgs[i] = make([]byte, 32)
+		_, _ = rand.Read(msgs[i])
+	}
+	tVal := dudect(*dudectSamples, func(class int, idx int) {
+		_, _ = scheme.Sign(keys[class], msgs[idx])
+	})
+	checkDudect(t, tVal)
+}
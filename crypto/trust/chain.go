@@ -0,0 +1,264 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"hash"
+	"math"
+	"time"
+)
+
+// A delegation chain lets an offline root key vouch for an ephemeral KEM key through time limited intermediate
+// signing keys. Every delegation is a SigData over the tagged (see pqc_crypto.MarshalTagged) intermediate public key
+// signed by the previous key, starting with the root, and the leaf is a SigData over the KEM public key signed by the
+// last intermediate:
+//
+//	"PQCCH" | version 1 | count u8 | count * (len u32 | tagged key | len u32 | SigData) | leaf SigData
+//
+// The leaf KEM key is not included, it is sent separately as with PublicKeySignedPacketPayload.
+
+var ErrInvalidChain = errors.New("invalid delegation chain")
+var ErrChainTooDeep = errors.New("delegation chain is too deep")
+var ErrLinkNotYetValid = errors.New("link is not valid yet")
+var ErrLinkExpired = errors.New("link has expired")
+
+const chainMagic = "PQCCH\x01"
+
+// DefaultMaxChainDepth is the number of intermediate keys allowed when ChainVerifier.MaxDepth is 0
+const DefaultMaxChainDepth = 1
+
+// Chain of delegations from a root key to the key signing Leaf
+type Chain struct {
+	Delegations []*crypto.SigData
+	Leaf        *crypto.SigData
+}
+
+// Delegate adds a delegation to key signed by signer, the root for the first delegation and the last delegated key
+// after that
+func (c *Chain) Delegate(signer crypto.SigPrivateKey, key crypto.SigPublicKey, issue, expiry time.Time, h hash.Hash) error {
+	if len(c.Delegations) == math.MaxUint8 {
+		return ErrChainTooDeep
+	}
+	tagged, err := pqc_crypto.MarshalTagged(key)
+	if err != nil {
+		return err
+	}
+	sigData, err := pqc_crypto.NewSigData(tagged, issue, expiry, h, signer)
+	if err != nil {
+		return err
+	}
+	if sigData == nil {
+		return crypto.ErrKeyNil
+	}
+	c.Delegations = append(c.Delegations, sigData)
+	return nil
+}
+
+// SignLeaf sets the leaf to kemKey signed by signer, the last delegated key
+func (c *Chain) SignLeaf(signer crypto.SigPrivateKey, kemKey crypto.KemPublicKey, issue, expiry time.Time, h hash.Hash) error {
+	if kemKey == nil {
+		return crypto.ErrKeyNil
+	}
+	bts, err := kemKey.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	sigData, err := pqc_crypto.NewSigData(bts, issue, expiry, h, signer)
+	if err != nil {
+		return err
+	}
+	if sigData == nil {
+		return crypto.ErrKeyNil
+	}
+	c.Leaf = sigData
+	return nil
+}
+
+func appendChainPart(b, part []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(part)))
+	return append(b, part...)
+}
+
+// MarshalBinary encodes the chain without the leaf KEM key
+func (c *Chain) MarshalBinary() ([]byte, error) {
+	if c.Leaf == nil || len(c.Delegations) > math.MaxUint8 {
+		return nil, ErrInvalidChain
+	}
+	b := append([]byte(chainMagic), byte(len(c.Delegations)))
+	for _, d := range c.Delegations {
+		sd, err := d.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = appendChainPart(b, d.PublicKey)
+		b = appendChainPart(b, sd)
+	}
+	leaf, err := c.Leaf.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, leaf...), nil
+}
+
+func readChainPart(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, ErrInvalidChain
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n) > uint64(len(b)-4) {
+		return nil, nil, ErrInvalidChain
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
+
+// UnmarshalBinary decodes a chain, the leaf has no KEM key
+func (c *Chain) UnmarshalBinary(b []byte) error {
+	if len(b) < len(chainMagic)+1 || string(b[:len(chainMagic)]) != chainMagic {
+		return ErrInvalidChain
+	}
+	count := int(b[len(chainMagic)])
+	b = b[len(chainMagic)+1:]
+	delegations := make([]*crypto.SigData, 0, count)
+	for range count {
+		var key, sd []byte
+		var err error
+		key, b, err = readChainPart(b)
+		if err != nil {
+			return err
+		}
+		sd, b, err = readChainPart(b)
+		if err != nil {
+			return err
+		}
+		sigData, err := crypto.UnmarshalSigData(sd, append([]byte(nil), key...))
+		if err != nil {
+			return err
+		}
+		delegations = append(delegations, sigData)
+	}
+	leaf, err := crypto.UnmarshalSigData(b, nil)
+	if err != nil {
+		return err
+	}
+	c.Delegations = delegations
+	c.Leaf = leaf
+	return nil
+}
+
+// ChainError names the link of a chain that failed verification
+type ChainError struct {
+	// Link is the index of the failing delegation, or the number of delegations for the leaf
+	Link int
+	Leaf bool
+	// Signer is the key ID of the key the link was meant to be signed by
+	Signer string
+	Err    error
+}
+
+func (e *ChainError) Error() string {
+	what := "delegation"
+	if e.Leaf {
+		what = "leaf"
+	}
+	return fmt.Sprintf("chain link %d (%s signed by key %s): %v", e.Link, what, e.Signer, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// ChainVerifier verifies chains starting at Root
+type ChainVerifier struct {
+	Root crypto.SigPublicKey
+	// MaxDepth is the number of intermediate keys allowed, DefaultMaxChainDepth if 0
+	MaxDepth int
+	// NewHash creates the hash the links were signed with, nil if they sign the full data
+	NewHash func() hash.Hash
+	// Verifier sets the clock and skew tolerance the links are checked with
+	Verifier pqc_crypto.SigDataVerifier
+	// Policy every signing key in the chain is checked against paired with the KEM, pqc_crypto.DefaultPolicy if nil
+	Policy *pqc_crypto.Policy
+}
+
+// Verify checks the validity window and signature of every link of c, ending with the leaf over kemKey, returning the
+// key that signed the leaf. A failing link is reported with a *ChainError
+func (v ChainVerifier) Verify(c *Chain, kemKey crypto.KemPublicKey) (crypto.SigPublicKey, error) {
+	if v.Root == nil || kemKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if c == nil || c.Leaf == nil {
+		return nil, ErrInvalidChain
+	}
+	maxDepth := v.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxChainDepth
+	}
+	if len(c.Delegations) > maxDepth {
+		return nil, fmt.Errorf("%w: %d intermediate keys, at most %d", ErrChainTooDeep, len(c.Delegations), maxDepth)
+	}
+	now := v.Verifier.Now()
+	signer := v.Root
+	for i, d := range c.Delegations {
+		if err := v.verifyLink(d, signer, kemKey.Scheme(), now); err != nil {
+			return nil, &ChainError{Link: i, Signer: signerID(signer), Err: err}
+		}
+		key, err := pqc_crypto.UnmarshalAnySigPublicKey(d.PublicKey)
+		if err != nil {
+			return nil, &ChainError{Link: i, Signer: signerID(signer), Err: err}
+		}
+		signer = key
+	}
+	leaf := *c.Leaf
+	bts, err := kemKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	leaf.PublicKey = bts
+	if err := v.verifyLink(&leaf, signer, kemKey.Scheme(), now); err != nil {
+		return nil, &ChainError{Link: len(c.Delegations), Leaf: true, Signer: signerID(signer), Err: err}
+	}
+	return signer, nil
+}
+
+func (v ChainVerifier) verifyLink(sigData *crypto.SigData, signer crypto.SigPublicKey, kem crypto.KemScheme, now time.Time) error {
+	if sigData == nil {
+		return ErrInvalidChain
+	}
+	var h hash.Hash
+	if v.NewHash != nil {
+		h = v.NewHash()
+	}
+	policy := pqc_crypto.DefaultPolicy
+	if v.Policy != nil {
+		policy = *v.Policy
+	}
+	if err := policy.CheckSigned(kem, signer.Scheme(), h); err != nil {
+		return err
+	}
+	if err := v.Verifier.CheckTime(sigData, now); errors.Is(err, pqc_crypto.ErrSigNotYetValid) {
+		return fmt.Errorf("%w: issued %s", ErrLinkNotYetValid, sigData.IssueTime.UTC().Format(time.RFC3339))
+	} else if err != nil {
+		return fmt.Errorf("%w: expired %s", ErrLinkExpired, sigData.ExpiryTime.UTC().Format(time.RFC3339))
+	}
+	if err := v.Verifier.VerifySignature(sigData, h, signer); errors.Is(err, pqc_crypto.ErrSigInvalid) {
+		return ErrVerifyFailed
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// signerID is the key ID of key for errors
+func signerID(key crypto.SigPublicKey) string {
+	if k, ok := key.(interface{ KeyID() string }); ok {
+		if id := k.KeyID(); id != "" {
+			return id
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,150 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	rootPk, root, err := pqc_crypto.WrapSig(mldsa65.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	interPk, inter := genSigKey(t)
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	now := time.Now()
+
+	c := &Chain{}
+	assert.NoError(t, c.Delegate(root, interPk, now.Add(-time.Minute), now.Add(24*time.Hour), sha256.New()))
+	assert.NoError(t, c.SignLeaf(inter, kemPk, now.Add(-time.Minute), now.Add(time.Hour), sha256.New()))
+
+	bts, err := c.MarshalBinary()
+	assert.NoError(t, err)
+	parsed := &Chain{}
+	assert.NoError(t, parsed.UnmarshalBinary(bts))
+	assert.Len(t, parsed.Delegations, 1)
+
+	v := ChainVerifier{Root: rootPk, NewHash: sha256.New}
+	signer, err := v.Verify(parsed, kemPk)
+	assert.NoError(t, err)
+	assert.True(t, interPk.Equals(signer))
+
+	// another KEM key
+	otherKem, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = v.Verify(parsed, otherKem)
+	var chainErr *ChainError
+	assert.ErrorAs(t, err, &chainErr)
+	assert.ErrorIs(t, err, ErrVerifyFailed)
+	assert.Equal(t, 1, chainErr.Link)
+	assert.True(t, chainErr.Leaf)
+	assert.Equal(t, interPk.(*pqc_crypto.SigPublicKeyWrapper).KeyID(), chainErr.Signer)
+	assert.Contains(t, err.Error(), "chain link 1 (leaf signed by key "+chainErr.Signer+")")
+
+	// another root
+	otherRoot, _ := genSigKey(t)
+	_, err = ChainVerifier{Root: otherRoot, NewHash: sha256.New}.Verify(parsed, kemPk)
+	assert.ErrorAs(t, err, &chainErr)
+	assert.ErrorIs(t, err, ErrVerifyFailed)
+	assert.Equal(t, 0, chainErr.Link)
+	assert.False(t, chainErr.Leaf)
+
+	// the root signing the leaf directly
+	direct := &Chain{}
+	assert.NoError(t, direct.SignLeaf(root, kemPk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	signer, err = ChainVerifier{Root: rootPk}.Verify(direct, kemPk)
+	assert.NoError(t, err)
+	assert.True(t, rootPk.Equals(signer))
+
+	_, err = v.Verify(&Chain{}, kemPk)
+	assert.ErrorIs(t, err, ErrInvalidChain)
+	_, err = ChainVerifier{}.Verify(parsed, kemPk)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
+
+func TestChainPolicy(t *testing.T) {
+	rootPk, root, err := pqc_crypto.WrapSig(mldsa65.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	interPk, inter := genSigKey(t)
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem1024.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	now := time.Now()
+	c := &Chain{}
+	assert.NoError(t, c.Delegate(root, interPk, now.Add(-time.Minute), now.Add(time.Hour), sha256.New()))
+	assert.NoError(t, c.SignLeaf(inter, kemPk, now.Add(-time.Minute), now.Add(time.Hour), sha256.New()))
+
+	// ML-DSA-44 is too weak to vouch for ML-KEM-1024
+	_, err = ChainVerifier{Root: rootPk, NewHash: sha256.New}.Verify(c, kemPk)
+	var chainErr *ChainError
+	assert.ErrorAs(t, err, &chainErr)
+	assert.ErrorIs(t, err, pqc_crypto.ErrLevelMismatch)
+	assert.True(t, chainErr.Leaf)
+	_, err = ChainVerifier{Root: rootPk, NewHash: sha256.New, Policy: &pqc_crypto.CNSA2Policy}.Verify(c, kemPk)
+	assert.ErrorIs(t, err, pqc_crypto.ErrSecurityLevel)
+	assert.ErrorAs(t, err, &chainErr)
+	assert.Equal(t, 0, chainErr.Link)
+	signer, err := ChainVerifier{Root: rootPk, NewHash: sha256.New, Policy: &pqc_crypto.NoPolicy}.Verify(c, kemPk)
+	assert.NoError(t, err)
+	assert.True(t, interPk.Equals(signer))
+}
+
+func TestChainWindowAndDepth(t *testing.T) {
+	rootPk, root := genSigKey(t)
+	interPk, inter := genSigKey(t)
+	inter2Pk, inter2 := genSigKey(t)
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	now := time.Now()
+
+	expired := &Chain{}
+	assert.NoError(t, expired.Delegate(root, interPk, now.Add(-2*time.Hour), now.Add(-time.Hour), nil))
+	assert.NoError(t, expired.SignLeaf(inter, kemPk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	v := ChainVerifier{Root: rootPk}
+	_, err = v.Verify(expired, kemPk)
+	var chainErr *ChainError
+	assert.ErrorAs(t, err, &chainErr)
+	assert.ErrorIs(t, err, ErrLinkExpired)
+	assert.Equal(t, 0, chainErr.Link)
+
+	future := &Chain{}
+	assert.NoError(t, future.Delegate(root, interPk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	assert.NoError(t, future.SignLeaf(inter, kemPk, now.Add(time.Minute), now.Add(time.Hour), nil))
+	_, err = v.Verify(future, kemPk)
+	assert.ErrorIs(t, err, ErrLinkNotYetValid)
+
+	skewed := ChainVerifier{Root: rootPk, Verifier: pqc_crypto.SigDataVerifier{Clock: pqc_crypto.FixedClock(now), IssueSkew: 2 * time.Minute}}
+	_, err = skewed.Verify(future, kemPk)
+	assert.NoError(t, err)
+	skewed.Verifier.ExpirySkew = 2 * time.Hour
+	_, err = skewed.Verify(expired, kemPk)
+	assert.NoError(t, err)
+
+	deep := &Chain{}
+	assert.NoError(t, deep.Delegate(root, interPk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	assert.NoError(t, deep.Delegate(inter, inter2Pk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	assert.NoError(t, deep.SignLeaf(inter2, kemPk, now.Add(-time.Minute), now.Add(time.Hour), nil))
+	_, err = v.Verify(deep, kemPk)
+	assert.ErrorIs(t, err, ErrChainTooDeep)
+	v.MaxDepth = 2
+	signer, err := v.Verify(deep, kemPk)
+	assert.NoError(t, err)
+	assert.True(t, inter2Pk.Equals(signer))
+}
+
+func TestChainUnmarshalInvalid(t *testing.T) {
+	c := &Chain{}
+	assert.ErrorIs(t, c.UnmarshalBinary(nil), ErrInvalidChain)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte("hello world")), ErrInvalidChain)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte(chainMagic+"\x01\xff\xff\xff\xff")), ErrInvalidChain)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte(chainMagic+"\x00")), crypto.ErrInvalidSigData)
+	_, err := c.MarshalBinary()
+	assert.ErrorIs(t, err, ErrInvalidChain)
+}
@@ -0,0 +1,133 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"hash"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DirStore is a Store keeping each key in its own file in a directory, the files hold the text form of the key (see
+// pqc_crypto.SigPublicKeyWrapper.MarshalText) and are named by the hex fingerprint with a .pub extension. Keys can
+// be added by dropping files into the directory and calling Reload.
+type DirStore struct {
+	mem   *MemoryStore
+	dir   string
+	mu    sync.Mutex
+	files map[string]string
+}
+
+const dirStoreExt = ".pub"
+
+// OpenDirStore loads the keys in dir, creating it if needed, indexing them with the hash from newHash, SHA-256 if nil
+func OpenDirStore(dir string, newHash func() hash.Hash) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	d := &DirStore{mem: NewMemoryStore(newHash), dir: dir}
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reload reads the keys in the directory again
+func (d *DirStore) Reload() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(d.dir, "*"+dirStoreExt))
+	if err != nil {
+		return err
+	}
+	keys := make([]crypto.SigPublicKey, 0, len(paths))
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return err
+		}
+		fp, err := Fingerprint(key, d.mem.newHash)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		files[string(fp)] = path
+	}
+	if err := d.mem.replace(keys); err != nil {
+		return err
+	}
+	d.files = files
+	return nil
+}
+
+func readKeyFile(path string) (crypto.SigPublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key := &pqc_crypto.SigPublicKeyWrapper{}
+	if err := key.UnmarshalText(bytes.TrimSpace(data)); err != nil {
+		return nil, &os.PathError{Op: "parse", Path: path, Err: err}
+	}
+	return key, nil
+}
+
+func (d *DirStore) Lookup(fingerprint []byte) (crypto.SigPublicKey, error) {
+	return d.mem.Lookup(fingerprint)
+}
+
+func (d *DirStore) Add(key crypto.SigPublicKey) ([]byte, error) {
+	fp, err := Fingerprint(key, d.mem.newHash)
+	if err != nil {
+		return nil, err
+	}
+	text, err := marshalKeyText(key)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.files[string(fp)]; ok {
+		return fp, nil
+	}
+	path := filepath.Join(d.dir, hex.EncodeToString(fp)+dirStoreExt)
+	if err := writeFileAtomic(path, append(text, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	d.files[string(fp)] = path
+	return d.mem.Add(key)
+}
+
+func (d *DirStore) Remove(fingerprint []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path, ok := d.files[string(fingerprint)]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(d.files, string(fingerprint))
+	return d.mem.Remove(fingerprint)
+}
+
+func (d *DirStore) List() ([]crypto.SigPublicKey, error) {
+	return d.mem.List()
+}
+
+// marshalKeyText returns the text form of keys loaded through pqc_crypto.SigWrapper
+func marshalKeyText(key crypto.SigPublicKey) ([]byte, error) {
+	t, ok := key.(encoding.TextMarshaler)
+	if !ok {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	return t.MarshalText()
+}
@@ -0,0 +1,130 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"hash"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// JSONStore is a Store kept in a single JSON file, rewritten atomically on every change:
+//
+//	{"keys": ["ML-DSA-44:<base64url key>", ...]}
+type JSONStore struct {
+	mem  *MemoryStore
+	path string
+	mu   sync.Mutex
+}
+
+type jsonStoreFile struct {
+	Keys []*pqc_crypto.SigPublicKeyWrapper `json:"keys"`
+}
+
+// OpenJSONStore loads the keys in the file at path, a missing file is an empty store, indexing them with the hash
+// from newHash, SHA-256 if nil
+func OpenJSONStore(path string, newHash func() hash.Hash) (*JSONStore, error) {
+	j := &JSONStore{mem: NewMemoryStore(newHash), path: path}
+	if err := j.Reload(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Reload reads the file again
+func (j *JSONStore) Reload() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j.mem.replace(nil)
+	}
+	if err != nil {
+		return err
+	}
+	var file jsonStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	keys := make([]crypto.SigPublicKey, 0, len(file.Keys))
+	for _, key := range file.Keys {
+		if key == nil || key.PublicKey == nil {
+			return crypto.ErrKeyNil
+		}
+		keys = append(keys, key)
+	}
+	return j.mem.replace(keys)
+}
+
+func (j *JSONStore) Lookup(fingerprint []byte) (crypto.SigPublicKey, error) {
+	return j.mem.Lookup(fingerprint)
+}
+
+func (j *JSONStore) Add(key crypto.SigPublicKey) ([]byte, error) {
+	if _, ok := key.(*pqc_crypto.SigPublicKeyWrapper); !ok {
+		if key == nil {
+			return nil, crypto.ErrKeyNil
+		}
+		return nil, crypto.ErrIncompatibleKey
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fp, err := Fingerprint(key, j.mem.newHash)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := j.mem.Lookup(fp); err == nil {
+		return fp, nil
+	}
+	if _, err := j.mem.Add(key); err != nil {
+		return nil, err
+	}
+	if err := j.save(); err != nil {
+		_ = j.mem.Remove(fp)
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (j *JSONStore) Remove(fingerprint []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key, err := j.mem.Lookup(fingerprint)
+	if err != nil {
+		return err
+	}
+	if err := j.mem.Remove(fingerprint); err != nil {
+		return err
+	}
+	if err := j.save(); err != nil {
+		_, _ = j.mem.Add(key)
+		return err
+	}
+	return nil
+}
+
+func (j *JSONStore) List() ([]crypto.SigPublicKey, error) {
+	return j.mem.List()
+}
+
+// save writes the file, j.mu must be held
+func (j *JSONStore) save() error {
+	keys, err := j.mem.List()
+	if err != nil {
+		return err
+	}
+	file := jsonStoreFile{Keys: make([]*pqc_crypto.SigPublicKeyWrapper, len(keys))}
+	for i, key := range keys {
+		file.Keys[i] = key.(*pqc_crypto.SigPublicKeyWrapper)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path, append(data, '\n'), 0644)
+}
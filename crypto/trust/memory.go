@@ -0,0 +1,83 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"slices"
+	"sync"
+)
+
+// MemoryStore is a Store held in memory
+type MemoryStore struct {
+	newHash func() hash.Hash
+	mu      sync.RWMutex
+	keys    map[string]crypto.SigPublicKey
+}
+
+// NewMemoryStore creates an empty store indexing keys by fingerprint with the hash from newHash, SHA-256 if nil
+func NewMemoryStore(newHash func() hash.Hash) *MemoryStore {
+	return &MemoryStore{newHash: newHash, keys: make(map[string]crypto.SigPublicKey)}
+}
+
+func (m *MemoryStore) Lookup(fingerprint []byte) (crypto.SigPublicKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if key, ok := m.keys[string(fingerprint)]; ok {
+		return key, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m *MemoryStore) Add(key crypto.SigPublicKey) ([]byte, error) {
+	fp, err := Fingerprint(key, m.newHash)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[string(fp)] = key
+	return fp, nil
+}
+
+func (m *MemoryStore) Remove(fingerprint []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[string(fingerprint)]; !ok {
+		return ErrNotFound
+	}
+	delete(m.keys, string(fingerprint))
+	return nil
+}
+
+func (m *MemoryStore) List() ([]crypto.SigPublicKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fps := make([]string, 0, len(m.keys))
+	for fp := range m.keys {
+		fps = append(fps, fp)
+	}
+	slices.Sort(fps)
+	keys := make([]crypto.SigPublicKey, len(fps))
+	for i, fp := range fps {
+		keys[i] = m.keys[fp]
+	}
+	return keys, nil
+}
+
+// replace swaps the whole index, used when reloading persistent stores
+func (m *MemoryStore) replace(keys []crypto.SigPublicKey) error {
+	index := make(map[string]crypto.SigPublicKey, len(keys))
+	for _, key := range keys {
+		fp, err := Fingerprint(key, m.newHash)
+		if err != nil {
+			return err
+		}
+		index[string(fp)] = key
+	}
+	m.mu.Lock()
+	m.keys = index
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,188 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trust on first use: the first key seen for a peer identity is pinned and later keys must match it until a new key
+// is explicitly approved
+
+var ErrKeyChanged = errors.New("peer key does not match the pinned key")
+var ErrNotPinned = errors.New("peer has no pinned key")
+
+// Pin is the key pinned for a peer
+type Pin struct {
+	Peer   string                          `json:"peer"`
+	Key    *pqc_crypto.SigPublicKeyWrapper `json:"key"`
+	Pinned time.Time                       `json:"pinned"`
+}
+
+type pinFile struct {
+	Pins []*Pin `json:"pins"`
+}
+
+// PinStore is a persistent TOFU store kept in a JSON file, which is rewritten atomically on every change
+type PinStore struct {
+	path string
+	mu   sync.Mutex
+	pins map[string]*Pin
+}
+
+// OpenPinStore loads the pins in the file at path, a missing file has no pins
+func OpenPinStore(path string) (*PinStore, error) {
+	p := &PinStore{path: path, pins: make(map[string]*Pin)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file pinFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, pin := range file.Pins {
+		if pin == nil || pin.Key == nil || pin.Key.PublicKey == nil {
+			return nil, crypto.ErrKeyNil
+		}
+		p.pins[pin.Peer] = pin
+	}
+	return p, nil
+}
+
+func wrappedSigKey(key crypto.SigPublicKey) (*pqc_crypto.SigPublicKeyWrapper, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	w, ok := key.(*pqc_crypto.SigPublicKeyWrapper)
+	if !ok {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	return w, nil
+}
+
+// Check pins key for peer if it has no pin, returning true, otherwise key must match the pinned key or an error
+// wrapping ErrKeyChanged is returned
+func (p *PinStore) Check(peer string, key crypto.SigPublicKey) (bool, error) {
+	w, err := wrappedSigKey(key)
+	if err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pin, ok := p.pins[peer]; ok {
+		if !pin.Key.Equals(w) {
+			return false, fmt.Errorf("%w: %s", ErrKeyChanged, peer)
+		}
+		return false, nil
+	}
+	return true, p.set(peer, w)
+}
+
+// CheckPayload loads the key in a SignedPacketSigPublicKeyPayload with scheme and checks it, see Check
+func (p *PinStore) CheckPayload(peer string, payload *packets.SignedPacketSigPublicKeyPayload, scheme crypto.SigScheme) (crypto.SigPublicKey, bool, error) {
+	key, err := payload.Load(scheme)
+	if err != nil {
+		return nil, false, err
+	}
+	first, err := p.Check(peer, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return key, first, nil
+}
+
+// Approve pins key for peer replacing any existing pin, for when a peer has legitimately changed key
+func (p *PinStore) Approve(peer string, key crypto.SigPublicKey) error {
+	w, err := wrappedSigKey(key)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.set(peer, w)
+}
+
+// Forget removes the pin for peer so the next key seen is pinned
+func (p *PinStore) Forget(peer string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pin, ok := p.pins[peer]
+	if !ok {
+		return ErrNotPinned
+	}
+	delete(p.pins, peer)
+	if err := p.save(); err != nil {
+		p.pins[peer] = pin
+		return err
+	}
+	return nil
+}
+
+// Pinned returns the pin for peer
+func (p *PinStore) Pinned(peer string) (Pin, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pin, ok := p.pins[peer]
+	if !ok {
+		return Pin{}, ErrNotPinned
+	}
+	return *pin, nil
+}
+
+// Peers lists the pinned peers in order
+func (p *PinStore) Peers() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peers := make([]string, 0, len(p.pins))
+	for peer := range p.pins {
+		peers = append(peers, peer)
+	}
+	slices.Sort(peers)
+	return peers
+}
+
+// set pins key and saves, p.mu must be held
+func (p *PinStore) set(peer string, key *pqc_crypto.SigPublicKeyWrapper) error {
+	old, hadOld := p.pins[peer]
+	p.pins[peer] = &Pin{Peer: peer, Key: key, Pinned: time.Now().UTC()}
+	if err := p.save(); err != nil {
+		if hadOld {
+			p.pins[peer] = old
+		} else {
+			delete(p.pins, peer)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the file, p.mu must be held
+func (p *PinStore) save() error {
+	file := pinFile{Pins: make([]*Pin, 0, len(p.pins))}
+	for _, pin := range p.pins {
+		file.Pins = append(file.Pins, pin)
+	}
+	slices.SortFunc(file.Pins, func(a, b *Pin) int {
+		return strings.Compare(a.Peer, b.Peer)
+	})
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,94 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	store, err := OpenPinStore(path)
+	assert.NoError(t, err)
+	pk1, _ := genSigKey(t)
+	pk2, _ := genSigKey(t)
+
+	first, err := store.Check("alice", pk1)
+	assert.NoError(t, err)
+	assert.True(t, first)
+	first, err = store.Check("alice", pk1)
+	assert.NoError(t, err)
+	assert.False(t, first)
+	_, err = store.Check("alice", pk2)
+	assert.ErrorIs(t, err, ErrKeyChanged)
+	first, err = store.Check("bob", pk2)
+	assert.NoError(t, err)
+	assert.True(t, first)
+	assert.Equal(t, []string{"alice", "bob"}, store.Peers())
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// pins persist
+	other, err := OpenPinStore(path)
+	assert.NoError(t, err)
+	_, err = other.Check("alice", pk2)
+	assert.ErrorIs(t, err, ErrKeyChanged)
+	pin, err := other.Pinned("bob")
+	assert.NoError(t, err)
+	assert.True(t, pk2.Equals(pin.Key))
+	assert.False(t, pin.Pinned.IsZero())
+
+	// re-approval replaces the pin
+	assert.NoError(t, store.Approve("alice", pk2))
+	first, err = store.Check("alice", pk2)
+	assert.NoError(t, err)
+	assert.False(t, first)
+	_, err = store.Check("alice", pk1)
+	assert.ErrorIs(t, err, ErrKeyChanged)
+
+	assert.NoError(t, store.Forget("alice"))
+	assert.ErrorIs(t, store.Forget("alice"), ErrNotPinned)
+	_, err = store.Pinned("alice")
+	assert.ErrorIs(t, err, ErrNotPinned)
+	first, err = store.Check("alice", pk1)
+	assert.NoError(t, err)
+	assert.True(t, first)
+
+	_, err = store.Check("carol", nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
+
+func TestPinStorePayload(t *testing.T) {
+	store, err := OpenPinStore(filepath.Join(t.TempDir(), "pins.json"))
+	assert.NoError(t, err)
+	scheme := pqc_crypto.WrapSig(mldsa44.Scheme())
+	pk1, _ := genSigKey(t)
+	pk2, _ := genSigKey(t)
+
+	payload := &packets.SignedPacketSigPublicKeyPayload{}
+	assert.NoError(t, payload.Save(pk1))
+	key, first, err := store.CheckPayload("alice", payload, scheme)
+	assert.NoError(t, err)
+	assert.True(t, first)
+	assert.True(t, pk1.Equals(key))
+
+	assert.NoError(t, payload.Save(pk2))
+	_, _, err = store.CheckPayload("alice", payload, scheme)
+	assert.ErrorIs(t, err, ErrKeyChanged)
+}
+
+func TestOpenPinStoreInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"pins":[{"peer":"alice","key":null}]}`), 0600))
+	_, err := OpenPinStore(path)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
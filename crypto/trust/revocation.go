@@ -0,0 +1,267 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"hash"
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// A revocation list holds the fingerprints of compromised signing keys with the time each was revoked, signed by a
+// root key:
+//
+//	"PQCRL" | version 1 | serial u64 | issued unix ms | count u32 | count * (len u8 | fingerprint | revoked unix ms) | signature
+//
+// Revocation relies on SigData.IssueTime, a holder of the compromised key can back date new signatures so the
+// revocation time should be when the key was last known to be safe
+
+var ErrRevoked = errors.New("signing key has been revoked")
+var ErrInvalidRevocationList = errors.New("invalid revocation list")
+var ErrRevocationListSignature = errors.New("revocation list signature is invalid")
+var ErrRevocationListRollback = errors.New("revocation list is older than the current list")
+var ErrRevocationListConflict = errors.New("revocation list differs from the current list with the same serial")
+
+const revocationListMagic = "PQCRL\x01"
+
+// Revocation of the key with Fingerprint from Time
+type Revocation struct {
+	Fingerprint []byte
+	Time        time.Time
+}
+
+// RevocationList is the content of a signed revocation list, Revocations is kept ordered by fingerprint
+type RevocationList struct {
+	// Serial increases with every update so an older list can't replace a newer one
+	Serial      uint64
+	Issued      time.Time
+	Revocations []Revocation
+}
+
+func (l *RevocationList) search(fingerprint []byte) (int, bool) {
+	return slices.BinarySearchFunc(l.Revocations, fingerprint, func(r Revocation, fp []byte) int {
+		return bytes.Compare(r.Fingerprint, fp)
+	})
+}
+
+// Revoke the key with fingerprint from at, replacing the time of an existing revocation
+func (l *RevocationList) Revoke(fingerprint []byte, at time.Time) {
+	at = time.UnixMilli(at.UnixMilli())
+	i, found := l.search(fingerprint)
+	if found {
+		l.Revocations[i].Time = at
+		return
+	}
+	l.Revocations = slices.Insert(l.Revocations, i, Revocation{Fingerprint: bytes.Clone(fingerprint), Time: at})
+}
+
+// Unrevoke removes the revocation of the key with fingerprint, returning if there was one
+func (l *RevocationList) Unrevoke(fingerprint []byte) bool {
+	i, found := l.search(fingerprint)
+	if found {
+		l.Revocations = slices.Delete(l.Revocations, i, i+1)
+	}
+	return found
+}
+
+// RevokedAt returns when the key with fingerprint was revoked
+func (l *RevocationList) RevokedAt(fingerprint []byte) (time.Time, bool) {
+	i, found := l.search(fingerprint)
+	if !found {
+		return time.Time{}, false
+	}
+	return l.Revocations[i].Time, true
+}
+
+func (l *RevocationList) marshalBody() ([]byte, error) {
+	if len(l.Revocations) > math.MaxUint32 {
+		return nil, ErrInvalidRevocationList
+	}
+	b := []byte(revocationListMagic)
+	b = binary.BigEndian.AppendUint64(b, l.Serial)
+	b = binary.BigEndian.AppendUint64(b, uint64(l.Issued.UnixMilli()))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(l.Revocations)))
+	for i, r := range l.Revocations {
+		if len(r.Fingerprint) == 0 || len(r.Fingerprint) > math.MaxUint8 {
+			return nil, ErrInvalidRevocationList
+		}
+		if i > 0 && bytes.Compare(l.Revocations[i-1].Fingerprint, r.Fingerprint) >= 0 {
+			return nil, ErrInvalidRevocationList
+		}
+		b = append(b, byte(len(r.Fingerprint)))
+		b = append(b, r.Fingerprint...)
+		b = binary.BigEndian.AppendUint64(b, uint64(r.Time.UnixMilli()))
+	}
+	return b, nil
+}
+
+// Sign the list with root returning the signed list
+func (l *RevocationList) Sign(root crypto.SigPrivateKey) ([]byte, error) {
+	if root == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	body, err := l.marshalBody()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := root.Scheme().Sign(root, body)
+	if err != nil {
+		return nil, err
+	}
+	return append(body, sig...), nil
+}
+
+// ParseRevocationList parses a signed list checking it was signed by root
+func ParseRevocationList(data []byte, root crypto.SigPublicKey) (*RevocationList, error) {
+	if root == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	if !bytes.HasPrefix(data, []byte(revocationListMagic)) {
+		return nil, ErrInvalidRevocationList
+	}
+	b := data[len(revocationListMagic):]
+	if len(b) < 20 {
+		return nil, ErrInvalidRevocationList
+	}
+	l := &RevocationList{
+		Serial: binary.BigEndian.Uint64(b),
+		Issued: time.UnixMilli(int64(binary.BigEndian.Uint64(b[8:]))),
+	}
+	count := binary.BigEndian.Uint32(b[16:])
+	b = b[20:]
+	// every revocation takes at least 10 bytes
+	if uint64(count) > uint64(len(b)/10) {
+		return nil, ErrInvalidRevocationList
+	}
+	l.Revocations = make([]Revocation, 0, count)
+	for range count {
+		if len(b) < 1 || len(b) < 1+int(b[0])+8 || b[0] == 0 {
+			return nil, ErrInvalidRevocationList
+		}
+		n := int(b[0])
+		r := Revocation{
+			Fingerprint: bytes.Clone(b[1 : 1+n]),
+			Time:        time.UnixMilli(int64(binary.BigEndian.Uint64(b[1+n:]))),
+		}
+		if len(l.Revocations) > 0 && bytes.Compare(l.Revocations[len(l.Revocations)-1].Fingerprint, r.Fingerprint) >= 0 {
+			return nil, ErrInvalidRevocationList
+		}
+		l.Revocations = append(l.Revocations, r)
+		b = b[1+n+8:]
+	}
+	body := data[:len(data)-len(b)]
+	ok, err := root.Scheme().Verify(root, body, b)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRevocationListSignature
+	}
+	return l, nil
+}
+
+// RevocationVerifier verifies SigData rejecting signatures issued by revoked keys at or after their revocation time,
+// the list can be updated while in use
+type RevocationVerifier struct {
+	newHash func() hash.Hash
+	list    atomic.Pointer[RevocationList]
+}
+
+// NewRevocationVerifier checks signatures against list, which must not be changed after, with fingerprints from
+// newHash, SHA-256 if nil
+func NewRevocationVerifier(list *RevocationList, newHash func() hash.Hash) *RevocationVerifier {
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	if list == nil {
+		list = &RevocationList{}
+	}
+	v := &RevocationVerifier{newHash: newHash}
+	v.list.Store(list)
+	return v
+}
+
+// List returns the current list
+func (v *RevocationVerifier) List() *RevocationList {
+	return v.list.Load()
+}
+
+// Update replaces the list, which must have a higher serial than the current list. ErrRevocationListRollback is
+// returned for a lower serial, a list with the same serial is only accepted, and ignored, if its content is identical
+// otherwise ErrRevocationListConflict is returned
+func (v *RevocationVerifier) Update(list *RevocationList) error {
+	if list == nil {
+		return ErrInvalidRevocationList
+	}
+	body, err := list.marshalBody()
+	if err != nil {
+		return err
+	}
+	for {
+		cur := v.list.Load()
+		if list.Serial < cur.Serial {
+			return ErrRevocationListRollback
+		}
+		if list.Serial == cur.Serial {
+			curBody, err := cur.marshalBody()
+			if err != nil || !bytes.Equal(body, curBody) {
+				return ErrRevocationListConflict
+			}
+			return nil
+		}
+		if v.list.CompareAndSwap(cur, list) {
+			return nil
+		}
+	}
+}
+
+// Check returns an error wrapping ErrRevoked if key was revoked at or before issued
+func (v *RevocationVerifier) Check(key crypto.SigPublicKey, issued time.Time) error {
+	fp, err := Fingerprint(key, v.newHash)
+	if err != nil {
+		return err
+	}
+	if at, ok := v.list.Load().RevokedAt(fp); ok && !issued.Before(at) {
+		return fmt.Errorf("%w: %s at %s", ErrRevoked, pqc_crypto.FingerprintHex(fp), at.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Verify is pqc_crypto.VerifySigData checking key was not revoked when sigData was issued, ErrVerifyFailed is
+// returned for an invalid or missing signature
+func (v *RevocationVerifier) Verify(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) error {
+	if key == nil {
+		return crypto.ErrKeyNil
+	}
+	if sigData == nil {
+		return ErrVerifyFailed
+	}
+	if err := v.Check(key, sigData.IssueTime); err != nil {
+		return err
+	}
+	ok, err := pqc_crypto.VerifySigData(sigData, h, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// VerifySignedPacket is VerifySignedPacket with the signature checked by Verify
+func (v *RevocationVerifier) VerifySignedPacket(resolver KeyResolver, payload *packets.PublicKeySignedPacketPayload, kemKey crypto.KemPublicKey, h hash.Hash) (crypto.SigPublicKey, error) {
+	return verifySignedPacket(resolver, payload, kemKey, func(sigData *crypto.SigData, key crypto.SigPublicKey) error {
+		return v.Verify(sigData, h, key)
+	})
+}
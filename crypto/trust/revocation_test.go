@@ -0,0 +1,122 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRevocationList(t *testing.T) {
+	rootPk, root := genSigKey(t)
+	now := time.UnixMilli(time.Now().UnixMilli())
+	l := &RevocationList{Serial: 3, Issued: now}
+	l.Revoke([]byte{3, 3}, now)
+	l.Revoke([]byte{1, 1}, now.Add(-time.Hour))
+	l.Revoke([]byte{2, 2}, now)
+	l.Revoke([]byte{1, 1}, now.Add(-2*time.Hour))
+	assert.Len(t, l.Revocations, 3)
+	at, ok := l.RevokedAt([]byte{1, 1})
+	assert.True(t, ok)
+	assert.True(t, now.Add(-2*time.Hour).Equal(at))
+	assert.True(t, l.Unrevoke([]byte{3, 3}))
+	assert.False(t, l.Unrevoke([]byte{3, 3}))
+	_, ok = l.RevokedAt([]byte{3, 3})
+	assert.False(t, ok)
+
+	signed, err := l.Sign(root)
+	assert.NoError(t, err)
+	parsed, err := ParseRevocationList(signed, rootPk)
+	assert.NoError(t, err)
+	assert.Equal(t, l.Serial, parsed.Serial)
+	assert.True(t, l.Issued.Equal(parsed.Issued))
+	assert.Len(t, parsed.Revocations, 2)
+	for i, r := range l.Revocations {
+		assert.Equal(t, r.Fingerprint, parsed.Revocations[i].Fingerprint)
+		assert.True(t, r.Time.Equal(parsed.Revocations[i].Time))
+	}
+
+	// signed by another key
+	otherPk, _ := genSigKey(t)
+	_, err = ParseRevocationList(signed, otherPk)
+	assert.ErrorIs(t, err, ErrRevocationListSignature)
+
+	// tampered
+	tampered := append([]byte{}, signed...)
+	tampered[len(revocationListMagic)+7]++
+	_, err = ParseRevocationList(tampered, rootPk)
+	assert.ErrorIs(t, err, ErrRevocationListSignature)
+	_, err = ParseRevocationList(signed[:20], rootPk)
+	assert.ErrorIs(t, err, ErrInvalidRevocationList)
+	_, err = ParseRevocationList([]byte("hello world"), rootPk)
+	assert.ErrorIs(t, err, ErrInvalidRevocationList)
+	_, err = ParseRevocationList(signed, nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
+
+func TestRevocationVerifier(t *testing.T) {
+	pk, k := genSigKey(t)
+	fp, err := Fingerprint(pk, nil)
+	assert.NoError(t, err)
+	revokedAt := time.Now().Add(-time.Hour)
+	v := NewRevocationVerifier(nil, nil)
+
+	before := crypto.NewSigData([]byte("data"), revokedAt.Add(-time.Minute), time.Now().Add(time.Hour), sha256.New(), k)
+	after := crypto.NewSigData([]byte("data"), revokedAt.Add(time.Minute), time.Now().Add(time.Hour), sha256.New(), k)
+	assert.NoError(t, v.Verify(before, sha256.New(), pk))
+	assert.NoError(t, v.Verify(after, sha256.New(), pk))
+
+	assert.NoError(t, v.Update(&RevocationList{Serial: 2, Revocations: []Revocation{{Fingerprint: fp, Time: revokedAt}}}))
+	assert.NoError(t, v.Verify(before, sha256.New(), pk))
+	assert.ErrorIs(t, v.Verify(after, sha256.New(), pk), ErrRevoked)
+
+	// invalid signatures are still rejected
+	other, _ := genSigKey(t)
+	assert.ErrorIs(t, v.Verify(before, sha256.New(), other), ErrVerifyFailed)
+	assert.ErrorIs(t, v.Verify(nil, sha256.New(), pk), ErrVerifyFailed)
+	assert.ErrorIs(t, v.Verify(before, sha256.New(), nil), crypto.ErrKeyNil)
+
+	assert.ErrorIs(t, v.Update(&RevocationList{Serial: 1}), ErrRevocationListRollback)
+	assert.Equal(t, uint64(2), v.List().Serial)
+
+	// the same serial is only accepted with the same content
+	current := v.List()
+	assert.ErrorIs(t, v.Update(&RevocationList{Serial: 2}), ErrRevocationListConflict)
+	assert.ErrorIs(t, v.Update(&RevocationList{Serial: 2, Revocations: []Revocation{{Fingerprint: fp, Time: revokedAt.Add(time.Hour)}}}), ErrRevocationListConflict)
+	assert.NoError(t, v.Update(&RevocationList{Serial: 2, Revocations: []Revocation{{Fingerprint: fp, Time: revokedAt}}}))
+	assert.Same(t, current, v.List())
+	assert.ErrorIs(t, v.Verify(after, sha256.New(), pk), ErrRevoked)
+
+	assert.NoError(t, v.Update(&RevocationList{Serial: 3}))
+	assert.NoError(t, v.Verify(after, sha256.New(), pk))
+}
+
+func TestRevocationVerifierSignedPacket(t *testing.T) {
+	store := NewMemoryStore(nil)
+	pk, k := genSigKey(t)
+	fp, err := store.Add(pk)
+	assert.NoError(t, err)
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	kemBts, err := kemPk.MarshalBinary()
+	assert.NoError(t, err)
+	payload := &packets.PublicKeySignedPacketPayload{SigPubKeyHash: fp}
+	assert.NoError(t, payload.Save(crypto.NewSigData(kemBts, time.Now(), time.Now().Add(time.Hour), sha256.New(), k)))
+
+	v := NewRevocationVerifier(nil, nil)
+	key, err := v.VerifySignedPacket(store, payload, kemPk, sha256.New())
+	assert.NoError(t, err)
+	assert.True(t, pk.Equals(key))
+
+	l := &RevocationList{Serial: 1}
+	l.Revoke(fp, time.Now().Add(-time.Minute))
+	assert.NoError(t, v.Update(l))
+	_, err = v.VerifySignedPacket(store, payload, kemPk, sha256.New())
+	assert.ErrorIs(t, err, ErrRevoked)
+}
@@ -0,0 +1,300 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"hash"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Key rotation replaces the signing key every Period. The next key is generated Announce before the rotation so its
+// fingerprint can be published, for the last Overlap before the rotation KEM keys are signed by both keys so peers
+// accept them with either, then the old key is retired and its private key dropped from the state file
+
+var ErrNoNextKey = errors.New("next signing key has not been announced")
+
+// DefaultRotationPeriod is how long each signing key is used when RotationConfig.Period is 0
+const DefaultRotationPeriod = 90 * 24 * time.Hour
+
+// DefaultRotationAnnounce is how long before the rotation the next key is published when RotationConfig.Announce is 0
+const DefaultRotationAnnounce = 14 * 24 * time.Hour
+
+// RotationConfig configures a Rotation
+type RotationConfig struct {
+	// Scheme generates the signing keys
+	Scheme crypto.SigScheme
+	// Period is how long each key is current, DefaultRotationPeriod if 0
+	Period time.Duration
+	// Announce is how long before the rotation the next key is generated, DefaultRotationAnnounce if 0, at most
+	// Period
+	Announce time.Duration
+	// Overlap is how long before the rotation both keys sign, Announce if 0, at most Announce
+	Overlap time.Duration
+	// Now is the clock, time.Now if nil
+	Now func() time.Time
+	// Policy the signing keys, and the KEM keys and hash passed to Sign, are checked against, pqc_crypto.DefaultPolicy
+	// if nil
+	Policy *pqc_crypto.Policy
+}
+
+// RotationKey is a signing key in the state file
+type RotationKey struct {
+	Key *pqc_crypto.SigPrivateKeyWrapper `json:"key"`
+	// Activated is when the key becomes current
+	Activated time.Time `json:"activated"`
+}
+
+type rotationFile struct {
+	Current *RotationKey `json:"current"`
+	Next    *RotationKey `json:"next,omitempty"`
+}
+
+// Rotation holds the current and next signing keys in a JSON file, which is rewritten atomically on every change and
+// should be kept private. The schedule is applied on demand, it is safe for concurrent use
+type Rotation struct {
+	path   string
+	config RotationConfig
+	mu     sync.Mutex
+	state  rotationFile
+}
+
+// OpenRotation loads the keys in the file at path, a missing file starts with a new key current from now
+func OpenRotation(path string, config RotationConfig) (*Rotation, error) {
+	if config.Scheme == nil {
+		return nil, pqc_crypto.ErrUnknownScheme
+	}
+	if config.Period <= 0 {
+		config.Period = DefaultRotationPeriod
+	}
+	if config.Announce <= 0 {
+		config.Announce = DefaultRotationAnnounce
+	}
+	config.Announce = min(config.Announce, config.Period)
+	if config.Overlap <= 0 || config.Overlap > config.Announce {
+		config.Overlap = config.Announce
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	if config.Policy == nil {
+		config.Policy = &pqc_crypto.DefaultPolicy
+	}
+	if err := config.Policy.CheckSig(config.Scheme); err != nil {
+		return nil, err
+	}
+	r := &Rotation{path: path, config: config}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &r.state); err != nil {
+			return nil, err
+		}
+		for _, k := range []*RotationKey{r.state.Current, r.state.Next} {
+			if k == nil {
+				continue
+			}
+			if k.Key == nil || k.Key.PrivateKey == nil {
+				return nil, crypto.ErrKeyNil
+			}
+			if err := config.Policy.CheckSig(k.Key.Scheme()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := r.Update(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// generate creates a key activated at t
+func (r *Rotation) generate(t time.Time) (*RotationKey, error) {
+	_, k, err := r.config.Scheme.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	w, ok := k.(*pqc_crypto.SigPrivateKeyWrapper)
+	if !ok {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	return &RotationKey{Key: w, Activated: t.UTC()}, nil
+}
+
+// update applies the schedule at now, saving any change, r.mu must be held
+func (r *Rotation) update(now time.Time) error {
+	state := r.state
+	var err error
+	if state.Current == nil {
+		if state.Current, err = r.generate(now); err != nil {
+			return err
+		}
+	}
+	for !now.Before(state.Current.Activated.Add(r.config.Period)) {
+		if state.Next == nil {
+			// the next key was never announced, start a new schedule now
+			if state.Next, err = r.generate(now); err != nil {
+				return err
+			}
+		}
+		state.Current, state.Next = state.Next, nil
+	}
+	rotates := state.Current.Activated.Add(r.config.Period)
+	if state.Next == nil && !now.Before(rotates.Add(-r.config.Announce)) {
+		if state.Next, err = r.generate(rotates); err != nil {
+			return err
+		}
+	}
+	if state == r.state {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(r.path, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	r.state = state
+	return nil
+}
+
+// Update applies the schedule now, retiring the old key if the rotation is due and generating the next key once it
+// should be announced, this also happens on demand in the other methods
+func (r *Rotation) Update() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(r.config.Now())
+}
+
+// Current returns the current signing key and when it is retired
+func (r *Rotation) Current() (crypto.SigPrivateKey, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.update(r.config.Now()); err != nil {
+		return nil, time.Time{}, err
+	}
+	return r.state.Current.Key, r.state.Current.Activated.Add(r.config.Period), nil
+}
+
+// Next returns the announced next public key and when it becomes current, ErrNoNextKey is returned before it is
+// announced
+func (r *Rotation) Next() (crypto.SigPublicKey, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.update(r.config.Now()); err != nil {
+		return nil, time.Time{}, err
+	}
+	if r.state.Next == nil {
+		return nil, time.Time{}, ErrNoNextKey
+	}
+	return r.state.Next.Key.Public(), r.state.Next.Activated, nil
+}
+
+// NextFingerprint is the fingerprint of the next key to publish ahead of the rotation, see Fingerprint and Next
+func (r *Rotation) NextFingerprint(newHash func() hash.Hash) ([]byte, time.Time, error) {
+	key, activated, err := r.Next()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fp, err := Fingerprint(key, newHash)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return fp, activated, nil
+}
+
+// Accepted returns the public keys peers should accept now, the current key followed by the next key once it is
+// announced
+func (r *Rotation) Accepted() ([]crypto.SigPublicKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.update(r.config.Now()); err != nil {
+		return nil, err
+	}
+	keys := []crypto.SigPublicKey{r.state.Current.Key.Public()}
+	if r.state.Next != nil {
+		keys = append(keys, r.state.Next.Key.Public())
+	}
+	return keys, nil
+}
+
+// Signers returns the keys to sign with now, the current key followed by the next key during the overlap
+func (r *Rotation) Signers() ([]crypto.SigPrivateKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.config.Now()
+	if err := r.update(now); err != nil {
+		return nil, err
+	}
+	keys := []crypto.SigPrivateKey{r.state.Current.Key}
+	rotates := r.state.Current.Activated.Add(r.config.Period)
+	if r.state.Next != nil && !now.Before(rotates.Add(-r.config.Overlap)) {
+		keys = append(keys, r.state.Next.Key)
+	}
+	return keys, nil
+}
+
+// Sign signs kemKey with every key from Signers, returning a payload for each with its SigPubKeyHash set to the SHA-256
+// fingerprint of the signing key
+func (r *Rotation) Sign(kemKey crypto.KemPublicKey, issue, expiry time.Time, h hash.Hash) ([]*packets.PublicKeySignedPacketPayload, error) {
+	if kemKey == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	bts, err := kemKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	signers, err := r.Signers()
+	if err != nil {
+		return nil, err
+	}
+	payloads := make([]*packets.PublicKeySignedPacketPayload, 0, len(signers))
+	for _, signer := range signers {
+		if err := r.config.Policy.CheckSigned(kemKey.Scheme(), signer.Scheme(), h); err != nil {
+			return nil, err
+		}
+		fp, err := Fingerprint(signer.Public(), nil)
+		if err != nil {
+			return nil, err
+		}
+		sigData, err := pqc_crypto.NewSigData(bts, issue, expiry, h, signer)
+		if err != nil {
+			return nil, err
+		}
+		if sigData == nil {
+			return nil, crypto.ErrIncompatibleKey
+		}
+		payload := &packets.PublicKeySignedPacketPayload{SigPubKeyHash: fp}
+		if err := payload.Save(sigData); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// VerifyAnySignedPacket is VerifySignedPacket accepting the first payload that verifies, for KEM keys signed by both
+// keys during a rotation. The error for the first payload is returned if none verify
+func VerifyAnySignedPacket(resolver KeyResolver, payloads []*packets.PublicKeySignedPacketPayload, kemKey crypto.KemPublicKey, h hash.Hash) (crypto.SigPublicKey, error) {
+	firstErr := ErrVerifyFailed
+	for i, payload := range payloads {
+		key, err := VerifySignedPacket(resolver, payload, kemKey, h)
+		if err == nil {
+			return key, nil
+		}
+		if i == 0 {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
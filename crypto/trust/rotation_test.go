@@ -0,0 +1,138 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"crypto/sha256"
+	"github.com/1f349/handshake/crypto"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotation.json")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	day := 24 * time.Hour
+	config := RotationConfig{
+		Scheme:   pqc_crypto.WrapSig(mldsa44.Scheme()),
+		Period:   90 * day,
+		Announce: 14 * day,
+		Overlap:  7 * day,
+		Now: func() time.Time {
+			return now
+		},
+	}
+	r, err := OpenRotation(path, config)
+	assert.NoError(t, err)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	first, retires, err := r.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(90*day), retires)
+	_, _, err = r.Next()
+	assert.ErrorIs(t, err, ErrNoNextKey)
+	signers, err := r.Signers()
+	assert.NoError(t, err)
+	assert.Len(t, signers, 1)
+
+	// the next key is announced
+	now = start.Add(77 * day)
+	next, activates, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(90*day), activates)
+	assert.False(t, next.Equals(first.Public()))
+	fp, _, err := r.NextFingerprint(nil)
+	assert.NoError(t, err)
+	want, err := Fingerprint(next, sha256.New)
+	assert.NoError(t, err)
+	assert.Equal(t, want, fp)
+	accepted, err := r.Accepted()
+	assert.NoError(t, err)
+	assert.Len(t, accepted, 2)
+	signers, err = r.Signers()
+	assert.NoError(t, err)
+	assert.Len(t, signers, 1)
+
+	// both keys sign during the overlap
+	now = start.Add(84 * day)
+	signers, err = r.Signers()
+	assert.NoError(t, err)
+	assert.Len(t, signers, 2)
+	kemKey, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	payloads, err := r.Sign(kemKey, time.Now(), time.Now().Add(time.Hour), sha256.New())
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 2)
+	for _, key := range []crypto.SigPublicKey{first.Public(), next} {
+		store := NewMemoryStore(nil)
+		_, err = store.Add(key)
+		assert.NoError(t, err)
+		signer, err := VerifyAnySignedPacket(store, payloads, kemKey, sha256.New())
+		assert.NoError(t, err)
+		assert.True(t, key.Equals(signer))
+	}
+	_, err = VerifyAnySignedPacket(NewMemoryStore(nil), payloads, kemKey, sha256.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the state persists
+	reopened, err := OpenRotation(path, config)
+	assert.NoError(t, err)
+	current, _, err := reopened.Current()
+	assert.NoError(t, err)
+	assert.True(t, first.Equals(current))
+	reopenedNext, _, err := reopened.Next()
+	assert.NoError(t, err)
+	assert.True(t, next.Equals(reopenedNext))
+
+	// the old key is retired
+	now = start.Add(90 * day)
+	current, retires, err = r.Current()
+	assert.NoError(t, err)
+	assert.True(t, next.Equals(current.Public()))
+	assert.Equal(t, start.Add(180*day), retires)
+	reopened, err = OpenRotation(path, config)
+	assert.NoError(t, err)
+	accepted, err = reopened.Accepted()
+	assert.NoError(t, err)
+	assert.Len(t, accepted, 1)
+	assert.True(t, next.Equals(accepted[0]))
+
+	// a missed rotation starts a new schedule
+	now = start.Add(400 * day)
+	current, retires, err = r.Current()
+	assert.NoError(t, err)
+	assert.False(t, next.Equals(current.Public()))
+	assert.Equal(t, now.Add(90*day), retires)
+}
+
+func TestRotationNoScheme(t *testing.T) {
+	_, err := OpenRotation(filepath.Join(t.TempDir(), "rotation.json"), RotationConfig{})
+	assert.ErrorIs(t, err, pqc_crypto.ErrUnknownScheme)
+}
+
+func TestRotationPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotation.json")
+	r, err := OpenRotation(path, RotationConfig{Scheme: pqc_crypto.WrapSig(mldsa44.Scheme())})
+	assert.NoError(t, err)
+	kemKey, _, err := pqc_crypto.WrapKem(mlkem1024.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = r.Sign(kemKey, time.Now(), time.Now().Add(time.Hour), sha256.New())
+	assert.ErrorIs(t, err, pqc_crypto.ErrLevelMismatch)
+
+	// the saved ML-DSA-44 key does not meet CNSA 2.0 even with an ML-DSA-87 scheme
+	_, err = OpenRotation(path, RotationConfig{Scheme: pqc_crypto.WrapSig(mldsa87.Scheme()), Policy: &pqc_crypto.CNSA2Policy})
+	assert.ErrorIs(t, err, pqc_crypto.ErrSecurityLevel)
+	_, err = OpenRotation(filepath.Join(t.TempDir(), "rotation.json"), RotationConfig{Scheme: pqc_crypto.WrapSig(mldsa44.Scheme()), Policy: &pqc_crypto.CNSA2Policy})
+	assert.ErrorIs(t, err, pqc_crypto.ErrSecurityLevel)
+}
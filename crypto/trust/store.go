@@ -0,0 +1,79 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	"hash"
+)
+
+// Trust stores hold the signature public keys a peer may sign with, indexed by their fingerprint (see
+// pqc_crypto.SigPublicKeyWrapper.Fingerprint) which is what PublicKeySignedPacketPayload.SigPubKeyHash carries
+
+var ErrNotFound = errors.New("key not found in trust store")
+var ErrVerifyFailed = errors.New("signature verification failed")
+
+// KeyResolver finds the signature public key for a fingerprint, this is all a handshake verifier needs
+type KeyResolver interface {
+	Lookup(fingerprint []byte) (crypto.SigPublicKey, error)
+}
+
+// Store is a KeyResolver that can be managed
+type Store interface {
+	KeyResolver
+	// Add a key returning its fingerprint, adding a key already in the store does nothing
+	Add(key crypto.SigPublicKey) ([]byte, error)
+	// Remove the key with the fingerprint, ErrNotFound is returned if there is none
+	Remove(fingerprint []byte) error
+	// List the keys ordered by fingerprint
+	List() ([]crypto.SigPublicKey, error)
+}
+
+// fingerprinter is implemented by pqc_crypto.SigPublicKeyWrapper
+type fingerprinter interface {
+	Fingerprint(h hash.Hash) ([]byte, error)
+}
+
+// Fingerprint of key with the hash from newHash, SHA-256 if nil
+func Fingerprint(key crypto.SigPublicKey, newHash func() hash.Hash) ([]byte, error) {
+	if key == nil {
+		return nil, crypto.ErrKeyNil
+	}
+	f, ok := key.(fingerprinter)
+	if !ok {
+		return nil, crypto.ErrIncompatibleKey
+	}
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	return f.Fingerprint(newHash())
+}
+
+// VerifySignedPacket resolves the signing key of a PublicKeySignedPacketPayload and verifies the signature over the
+// KEM public key, the signing key is returned when valid
+func VerifySignedPacket(resolver KeyResolver, payload *packets.PublicKeySignedPacketPayload, kemKey crypto.KemPublicKey, h hash.Hash) (crypto.SigPublicKey, error) {
+	return verifySignedPacket(resolver, payload, kemKey, func(sigData *crypto.SigData, key crypto.SigPublicKey) error {
+		if !sigData.Verify(h, key) {
+			return ErrVerifyFailed
+		}
+		return nil
+	})
+}
+
+func verifySignedPacket(resolver KeyResolver, payload *packets.PublicKeySignedPacketPayload, kemKey crypto.KemPublicKey, verify func(sigData *crypto.SigData, key crypto.SigPublicKey) error) (crypto.SigPublicKey, error) {
+	key, err := resolver.Lookup(payload.SigPubKeyHash)
+	if err != nil {
+		return nil, err
+	}
+	sigData, err := payload.Load(kemKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(&sigData, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,159 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package trust
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"github.com/1f349/handshake/crypto"
+	"github.com/1f349/handshake/net/packets"
+	pqc_crypto "github.com/1f349/pqc-handshake/crypto"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func genSigKey(t *testing.T) (crypto.SigPublicKey, crypto.SigPrivateKey) {
+	pk, k, err := pqc_crypto.WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	return pk, k
+}
+
+func testStore(t *testing.T, store Store) {
+	pk1, _ := genSigKey(t)
+	pk2, _, err := pqc_crypto.WrapSig(mldsa65.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+
+	fp1, err := store.Add(pk1)
+	assert.NoError(t, err)
+	fp2, err := store.Add(pk2)
+	assert.NoError(t, err)
+	again, err := store.Add(pk1)
+	assert.NoError(t, err)
+	assert.Equal(t, fp1, again)
+
+	key, err := store.Lookup(fp1)
+	assert.NoError(t, err)
+	assert.True(t, pk1.Equals(key))
+	key, err = store.Lookup(fp2)
+	assert.NoError(t, err)
+	assert.True(t, pk2.Equals(key))
+	_, err = store.Lookup([]byte{0, 1, 2, 3})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	keys, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	assert.NoError(t, store.Remove(fp1))
+	assert.ErrorIs(t, store.Remove(fp1), ErrNotFound)
+	_, err = store.Lookup(fp1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	keys, err = store.List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	_, err = store.Add(nil)
+	assert.ErrorIs(t, err, crypto.ErrKeyNil)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(nil))
+}
+
+func TestDirStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "trusted")
+	store, err := OpenDirStore(dir, nil)
+	assert.NoError(t, err)
+	testStore(t, store)
+
+	// another store on the same directory sees the same keys
+	pk, _ := genSigKey(t)
+	fp, err := store.Add(pk)
+	assert.NoError(t, err)
+	other, err := OpenDirStore(dir, nil)
+	assert.NoError(t, err)
+	keys, err := other.List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	key, err := other.Lookup(fp)
+	assert.NoError(t, err)
+	assert.True(t, pk.Equals(key))
+
+	// files dropped into the directory are picked up by Reload
+	pk2, _ := genSigKey(t)
+	text, err := pk2.(*pqc_crypto.SigPublicKeyWrapper).MarshalText()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "peer.pub"), text, 0644))
+	assert.NoError(t, store.Reload())
+	fp2, err := Fingerprint(pk2, nil)
+	assert.NoError(t, err)
+	_, err = store.Lookup(fp2)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Remove(fp2))
+	_, err = os.Stat(filepath.Join(dir, "peer.pub"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "bad.pub"), []byte("junk"), 0644))
+	_, err = OpenDirStore(dir, nil)
+	assert.Error(t, err)
+}
+
+func TestJSONStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trusted.json")
+	store, err := OpenJSONStore(path, sha512.New)
+	assert.NoError(t, err)
+	testStore(t, store)
+
+	other, err := OpenJSONStore(path, sha512.New)
+	assert.NoError(t, err)
+	keys, err := other.List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	fp, err := Fingerprint(keys[0], sha512.New)
+	assert.NoError(t, err)
+	assert.Len(t, fp, sha512.Size)
+	_, err = other.Lookup(fp)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"keys":["ML-DSA-44:AAAA"]}`), 0644))
+	assert.Error(t, store.Reload())
+	assert.NoError(t, os.WriteFile(path, []byte(`{"keys":[null]}`), 0644))
+	assert.ErrorIs(t, store.Reload(), crypto.ErrKeyNil)
+}
+
+func TestVerifySignedPacket(t *testing.T) {
+	store := NewMemoryStore(nil)
+	pk, k := genSigKey(t)
+	fp, err := store.Add(pk)
+	assert.NoError(t, err)
+	expected, err := pk.(*pqc_crypto.SigPublicKeyWrapper).Fingerprint(sha256.New())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, fp)
+
+	kemPk, _, err := pqc_crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	kemBts, err := kemPk.MarshalBinary()
+	assert.NoError(t, err)
+	payload := &packets.PublicKeySignedPacketPayload{SigPubKeyHash: fp}
+	assert.NoError(t, payload.Save(crypto.NewSigData(kemBts, time.Now(), time.Now().Add(time.Hour), sha256.New(), k)))
+
+	key, err := VerifySignedPacket(store, payload, kemPk, sha256.New())
+	assert.NoError(t, err)
+	assert.True(t, pk.Equals(key))
+
+	// signed by a key that is not the one named
+	_, other := genSigKey(t)
+	assert.NoError(t, payload.Save(crypto.NewSigData(kemBts, time.Now(), time.Now().Add(time.Hour), sha256.New(), other)))
+	_, err = VerifySignedPacket(store, payload, kemPk, sha256.New())
+	assert.ErrorIs(t, err, ErrVerifyFailed)
+
+	payload.SigPubKeyHash = []byte{0, 1, 2, 3}
+	_, err = VerifySignedPacket(store, payload, kemPk, sha256.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
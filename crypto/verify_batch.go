@@ -0,0 +1,89 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"context"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// VerifyItem is one signature for VerifyBatch, either SigData or a Signature over Message
+type VerifyItem struct {
+	Key crypto.SigPublicKey
+	// SigData is verified with a hash from NewHash, or over the full data if NewHash is nil, see crypto.SigData.Verify
+	SigData *crypto.SigData
+	NewHash func() hash.Hash
+	// Message and Signature are verified with the scheme of Key when SigData is nil
+	Message   []byte
+	Signature []byte
+}
+
+// VerifyResult of a VerifyItem, Err is set if the item could not be verified
+type VerifyResult struct {
+	Valid bool
+	Err   error
+}
+
+func (v VerifyItem) verify() VerifyResult {
+	if v.Key == nil {
+		return VerifyResult{Err: crypto.ErrKeyNil}
+	}
+	var valid bool
+	var err error
+	if v.SigData != nil {
+		var h hash.Hash
+		if v.NewHash != nil {
+			h = v.NewHash()
+		}
+		valid, err = VerifySigData(v.SigData, h, v.Key)
+	} else {
+		valid, err = v.Key.Scheme().Verify(v.Key, v.Message, v.Signature)
+	}
+	return VerifyResult{Valid: valid && err == nil, Err: err}
+}
+
+// VerifyBatch verifies items across workers goroutines, GOMAXPROCS if workers is not positive, returning the results
+// in the same order
+func VerifyBatch(items []VerifyItem, workers int) []VerifyResult {
+	results, _ := VerifyBatchContext(context.Background(), items, workers)
+	return results
+}
+
+// VerifyBatchContext is VerifyBatch stopping when ctx is done, the items not verified by then have ctx.Err() as their
+// error which is also returned
+func VerifyBatchContext(ctx context.Context, items []VerifyItem, workers int) ([]VerifyResult, error) {
+	results := make([]VerifyResult, len(items))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(items) {
+					return
+				}
+				results[i] = items[i].verify()
+			}
+		}()
+	}
+	wg.Wait()
+	// every index taken has been verified, workers only stop early once ctx is done
+	if n := int(next.Load()); n < len(items) {
+		err := ctx.Err()
+		for i := n; i < len(items); i++ {
+			results[i].Err = err
+		}
+		return results, err
+	}
+	return results, nil
+}
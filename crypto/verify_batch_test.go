@@ -0,0 +1,99 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testVerifyItems(t testing.TB, n int) ([]VerifyItem, []bool) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPk, _, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make([]VerifyItem, n)
+	valid := make([]bool, n)
+	for i := range items {
+		data := []byte(fmt.Sprintf("key %d", i))
+		key := pk
+		if i%3 == 2 {
+			key = otherPk
+		}
+		valid[i] = i%3 != 2
+		if i%2 == 0 {
+			items[i] = VerifyItem{Key: key, SigData: crypto.NewSigData(data, time.Now(), time.Now().Add(time.Hour), sha256.New(), k), NewHash: sha256.New}
+			continue
+		}
+		sig, err := WrapSig(mldsa44.Scheme()).Sign(k, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items[i] = VerifyItem{Key: key, Message: data, Signature: sig}
+	}
+	return items, valid
+}
+
+func TestVerifyBatch(t *testing.T) {
+	items, valid := testVerifyItems(t, 30)
+	items = append(items, VerifyItem{})
+	valid = append(valid, false)
+	for _, workers := range []int{0, 1, 4, 100} {
+		results := VerifyBatch(items, workers)
+		assert.Len(t, results, len(items))
+		for i, r := range results {
+			assert.Equal(t, valid[i], r.Valid, i)
+			if i == len(items)-1 {
+				assert.ErrorIs(t, r.Err, crypto.ErrKeyNil)
+			} else {
+				assert.NoError(t, r.Err)
+			}
+		}
+	}
+	assert.Empty(t, VerifyBatch(nil, 4))
+}
+
+func TestVerifyBatchContext(t *testing.T) {
+	items, _ := testVerifyItems(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := VerifyBatchContext(ctx, items, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+	for _, r := range results {
+		assert.False(t, r.Valid)
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+
+	results, err = VerifyBatchContext(context.Background(), items, 2)
+	assert.NoError(t, err)
+	assert.True(t, results[0].Valid)
+}
+
+// BenchmarkVerifyBatch verifies 256 signatures with increasing workers, use -cpu to compare GOMAXPROCS values
+// BenchmarkVerifyBatch compares one worker with GOMAXPROCS workers, run with -cpu 1,2,4,8 to see the scaling
+func BenchmarkVerifyBatch(b *testing.B) {
+	items, _ := testVerifyItems(b, 256)
+	for _, workers := range []int{1, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=GOMAXPROCS"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				VerifyBatch(items, workers)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(items)), "ns/sig")
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/1f349/handshake/crypto"
+	"hash"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultVerifyCacheSize is the number of entries kept when NewVerifyCache is given a size below 1
+const DefaultVerifyCacheSize = 4096
+
+// VerifyCache remembers valid SigData until their ExpiryTime so repeated verifications skip the signature check, it
+// is bounded by evicting the least recently used entry and is safe for concurrent use. Only valid results are cached
+type VerifyCache struct {
+	size    int
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+	stats   VerifyCacheStats
+}
+
+type verifyCacheEntry struct {
+	key    [sha256.Size]byte
+	expiry time.Time
+}
+
+// VerifyCacheStats counts cache use
+type VerifyCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// NewVerifyCache holds up to size entries
+func NewVerifyCache(size int) *VerifyCache {
+	if size < 1 {
+		size = DefaultVerifyCacheSize
+	}
+	return &VerifyCache{size: size, entries: make(map[[sha256.Size]byte]*list.Element), lru: list.New()}
+}
+
+// verifyCacheKey digests the signing key fingerprint, the hash type and size and the signed data
+func verifyCacheKey(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) ([sha256.Size]byte, bool) {
+	var k [sha256.Size]byte
+	fp, err := fingerprint(sha256.New(), key)
+	if err != nil {
+		return k, false
+	}
+	sd, err := sigData.MarshalBinary()
+	if err != nil {
+		return k, false
+	}
+	d := sha256.New()
+	d.Write(fp)
+	// the type is shared between sizes, such as SHA-224 and SHA-256, so the size is included
+	hashName := "none"
+	if h != nil {
+		hashName = reflect.TypeOf(h).String() + "/" + strconv.Itoa(h.Size())
+	}
+	d.Write(binary.BigEndian.AppendUint16(nil, uint16(len(hashName))))
+	d.Write([]byte(hashName))
+	d.Write(binary.BigEndian.AppendUint64(nil, uint64(len(sigData.PublicKey))))
+	d.Write(sigData.PublicKey)
+	d.Write(sd)
+	d.Sum(k[:0])
+	return k, true
+}
+
+// Verify is crypto.SigData.Verify (checked with VerifySigData) using the cache
+func (c *VerifyCache) Verify(sigData *crypto.SigData, h hash.Hash, key crypto.SigPublicKey) bool {
+	if sigData == nil || key == nil || CheckSigHash(h) != nil {
+		return false
+	}
+	k, ok := verifyCacheKey(sigData, h, key)
+	if !ok {
+		return false
+	}
+	if c.lookup(k) {
+		return true
+	}
+	valid, err := VerifySigData(sigData, h, key)
+	if err != nil || !valid {
+		return false
+	}
+	c.add(k, sigData.ExpiryTime)
+	return true
+}
+
+func (c *VerifyCache) lookup(k [sha256.Size]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[k]
+	if ok && time.Now().After(e.Value.(*verifyCacheEntry).expiry) {
+		c.remove(e)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return true
+}
+
+func (c *VerifyCache) add(k [sha256.Size]byte, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[k]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	c.entries[k] = c.lru.PushFront(&verifyCacheEntry{key: k, expiry: expiry})
+}
+
+// remove e, c.mu must be held
+func (c *VerifyCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*verifyCacheEntry).key)
+}
+
+// Stats returns the counts so far
+func (c *VerifyCache) Stats() VerifyCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+// Purge removes every entry, the statistics are kept
+func (c *VerifyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+}
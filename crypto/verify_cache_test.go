@@ -0,0 +1,118 @@
+// (C) 1f349 2025 - BSD-3-Clause License
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"github.com/1f349/handshake/crypto"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyCache(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	otherPk, _, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	c := NewVerifyCache(2)
+	sd := crypto.NewSigData([]byte("key"), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+
+	assert.True(t, c.Verify(sd, sha256.New(), pk))
+	assert.True(t, c.Verify(sd, sha256.New(), pk))
+	assert.Equal(t, VerifyCacheStats{Hits: 1, Misses: 1, Entries: 1}, c.Stats())
+
+	// another key, hash, data or signature is a different entry
+	assert.False(t, c.Verify(sd, sha256.New(), otherPk))
+	assert.False(t, c.Verify(sd, sha512.New(), pk))
+	// SHA-224 and SHA-512/256 share the types of SHA-256 and SHA-512
+	assert.False(t, c.Verify(sd, sha256.New224(), pk))
+	assert.False(t, c.Verify(sd, sha512.New512_256(), pk))
+	assert.False(t, c.Verify(sd, nil, pk))
+	changed := *sd
+	changed.PublicKey = []byte("other key")
+	assert.False(t, c.Verify(&changed, sha256.New(), pk))
+	changed = *sd
+	changed.Signature = append([]byte{}, sd.Signature...)
+	changed.Signature[0] ^= 1
+	assert.False(t, c.Verify(&changed, sha256.New(), pk))
+	assert.Equal(t, VerifyCacheStats{Hits: 1, Misses: 8, Entries: 1}, c.Stats())
+
+	// least recently used entries are evicted
+	sd2 := crypto.NewSigData([]byte("key 2"), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	sd3 := crypto.NewSigData([]byte("key 3"), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	assert.True(t, c.Verify(sd2, sha256.New(), pk))
+	assert.True(t, c.Verify(sd, sha256.New(), pk))
+	assert.True(t, c.Verify(sd3, sha256.New(), pk))
+	s := c.Stats()
+	assert.Equal(t, uint64(1), s.Evictions)
+	assert.Equal(t, 2, s.Entries)
+	assert.True(t, c.Verify(sd, sha256.New(), pk))
+	assert.Equal(t, s.Hits+1, c.Stats().Hits)
+	assert.True(t, c.Verify(sd2, sha256.New(), pk))
+	assert.Equal(t, s.Misses+1, c.Stats().Misses)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Entries)
+	assert.False(t, c.Verify(nil, sha256.New(), pk))
+	assert.False(t, c.Verify(sd, sha256.New(), nil))
+}
+
+func TestVerifyCacheExpiry(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	c := NewVerifyCache(0)
+	sd := crypto.NewSigData([]byte("key"), time.Now(), time.Now().Add(50*time.Millisecond), nil, k)
+	assert.True(t, c.Verify(sd, nil, pk))
+	assert.True(t, c.Verify(sd, nil, pk))
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, c.Verify(sd, nil, pk))
+	assert.Equal(t, VerifyCacheStats{Hits: 1, Misses: 2}, c.Stats())
+}
+
+func TestVerifyCacheConcurrent(t *testing.T) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	assert.NoError(t, err)
+	c := NewVerifyCache(8)
+	sds := make([]*crypto.SigData, 16)
+	for i := range sds {
+		sds[i] = crypto.NewSigData([]byte(fmt.Sprint(i)), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	}
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 64 {
+				assert.True(t, c.Verify(sds[(w+i)%len(sds)], sha256.New(), pk))
+			}
+		}()
+	}
+	wg.Wait()
+	s := c.Stats()
+	assert.Equal(t, uint64(8*64), s.Hits+s.Misses)
+	assert.LessOrEqual(t, s.Entries, 8)
+}
+
+func BenchmarkVerifyCache(b *testing.B) {
+	pk, k, err := WrapSig(mldsa44.Scheme()).GenerateKeyPair()
+	if err != nil {
+		b.Fatal(err)
+	}
+	sd := crypto.NewSigData([]byte("key"), time.Now(), time.Now().Add(time.Hour), sha256.New(), k)
+	b.Run("Uncached", func(b *testing.B) {
+		for b.Loop() {
+			sd.Verify(sha256.New(), pk)
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		c := NewVerifyCache(0)
+		for b.Loop() {
+			c.Verify(sd, sha256.New(), pk)
+		}
+	})
+}
@@ -6,6 +6,7 @@ require (
 	github.com/1f349/handshake v0.0.0-20251016195541-491ab70c6595
 	github.com/cloudflare/circl v1.6.1
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/crypto v0.43.0
 )
 
 require (
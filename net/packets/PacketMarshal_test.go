@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"github.com/1f349/handshake/net/packets"
 	"github.com/1f349/pqc-handshake/crypto"
 	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
@@ -314,3 +315,60 @@ func (m *fixedTransport) Write(p []byte) (n int, err error) {
 	m.queue = append(m.queue, cpy)
 	return
 }
+
+func BenchmarkPacketMarshal(b *testing.B) {
+	var payloads = []struct {
+		name    string
+		id      packets.PacketType
+		payload packets.PacketPayload
+	}{
+		{"Empty", packets.PublicKeyRequestPacketType, &packets.EmptyPayload{}},
+		{"PublicKey", packets.PublicKeyDataPacketType, GetValidPublicKeyPayload()},
+		{"SignedPacketSigPublicKey", packets.SignedPacketSigPublicKeyPacketType, GetValidSignedPacketSigPublicKeyPayload()},
+		{"PublicKeySigned", packets.PublicKeySignedPacketType, GetValidPublicKeySignedPacketPayload()},
+	}
+	for _, mtu := range []uint{0, 9000, 1500, 1280, 576} {
+		for _, p := range payloads {
+			b.Run(fmt.Sprintf("MTU %d/%s", mtu, p.name), func(b *testing.B) {
+				var transport io.ReadWriter = new(bytes.Buffer)
+				if mtu > 0 {
+					transport = newMTUTransport(int(mtu))
+				}
+				marshal := &packets.PacketMarshaller{Conn: transport, MTU: mtu}
+				header := packets.PacketHeader{ID: p.id, ConnectionUUID: packets.GetUUID(), Time: packets.MilliTime(time.Now())}
+				b.Run("Marshal", func(b *testing.B) {
+					b.ReportAllocs()
+					for b.Loop() {
+						if err := marshal.Marshal(header, p.payload); err != nil {
+							b.Fatal(err)
+						}
+						b.StopTimer()
+						drainPackets(b, marshal)
+						b.StartTimer()
+					}
+				})
+				b.Run("Unmarshal", func(b *testing.B) {
+					b.ReportAllocs()
+					for b.Loop() {
+						b.StopTimer()
+						if err := marshal.Marshal(header, p.payload); err != nil {
+							b.Fatal(err)
+						}
+						b.StartTimer()
+						drainPackets(b, marshal)
+					}
+				})
+			})
+		}
+	}
+}
+
+func drainPackets(b *testing.B, marshal *packets.PacketMarshaller) {
+	err := packets.ErrFragmentReceived
+	for errors.Is(err, packets.ErrFragmentReceived) {
+		_, _, err = marshal.Unmarshal()
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+}
@@ -41,14 +41,11 @@ func GetValidPublicKeySignedPacketPayload() *packets.PublicKeySignedPacketPayloa
 		if err != nil {
 			panic(err)
 		}
-		skbts, err := validPublicKeySignedPacketPayloadSigPubKey.MarshalBinary()
+		sigData := crypto.NewSigData(kbts, time.Now(), time.Now().Add(time.Hour), shash, pk)
+		validPublicKeySignedPacketPayloadSigPubKeyHash, err = validPublicKeySignedPacketPayloadSigPubKey.(*pqc_crypto.SigPublicKeyWrapper).Fingerprint(shash)
 		if err != nil {
 			panic(err)
 		}
-		sigData := crypto.NewSigData(kbts, time.Now(), time.Now().Add(time.Hour), shash, pk)
-		shash.Reset()
-		shash.Write(skbts)
-		validPublicKeySignedPacketPayloadSigPubKeyHash = shash.Sum(nil)
 		validPublicKeySignedPacketPayload = &packets.PublicKeySignedPacketPayload{SigPubKeyHash: validPublicKeySignedPacketPayloadSigPubKeyHash}
 		err = validPublicKeySignedPacketPayload.Save(sigData)
 		if err != nil {
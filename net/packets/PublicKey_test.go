@@ -8,6 +8,7 @@ import (
 	"github.com/1f349/pqc-handshake/crypto"
 	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"testing"
 )
 
@@ -77,3 +78,26 @@ func TestInvalidPublicKeyPayload(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, k)
 }
+
+func BenchmarkPublicKeyPayload(b *testing.B) {
+	k, _, err := crypto.WrapKem(mlkem768.Scheme()).GenerateKeyPair()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Save", func(b *testing.B) {
+		b.ReportAllocs()
+		payload := &packets.PublicKeyPayload{}
+		for b.Loop() {
+			_ = payload.Save(k)
+			_, _ = payload.WriteTo(io.Discard)
+		}
+	})
+	b.Run("AppendBinary", func(b *testing.B) {
+		b.ReportAllocs()
+		payload := &packets.PublicKeyPayload{}
+		for b.Loop() {
+			payload.Data, _ = k.(*crypto.KemPublicKeyWrapper).AppendBinary(payload.Data[:0])
+			_, _ = payload.WriteTo(io.Discard)
+		}
+	})
+}